@@ -0,0 +1,50 @@
+// Package blob stores the contents of uploaded files. Metadata lives in the
+// db package; a blob is only addressed by the key the handlers derive from it.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Store is implemented by every blob backend. Keys are slash-separated and
+// generated by the server, never taken from user input.
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get returns ErrNotFound for a missing key. The caller closes the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Open builds the store selected by BLOB_DRIVER ("local" by default, "s3" for
+// any S3-compatible service).
+func Open() (Store, error) {
+	switch driver := os.Getenv("BLOB_DRIVER"); driver {
+	case "", "local":
+		dir := os.Getenv("BLOB_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		s, err := NewLocalStore(dir)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("storing uploads in %s", dir)
+		return s, nil
+	case "s3":
+		s, err := NewS3Store()
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("storing uploads in bucket %s at %s", s.Bucket, s.Endpoint)
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown BLOB_DRIVER %q", driver)
+	}
+}
@@ -0,0 +1,505 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+var (
+	bucketForms      = []byte("forms")
+	bucketRevisions  = []byte("form_revisions")
+	bucketResponses  = []byte("responses")
+	bucketUsers      = []byte("users")
+	bucketEmails     = []byte("users_by_email")
+	bucketWorkspaces = []byte("workspaces")
+	bucketUploads    = []byte("uploads")
+)
+
+// BoltStore is an embedded, single-file backend for deployments where running
+// MongoDB is overkill. Documents are stored BSON-encoded, same as MemoryStore.
+// Revision and response keys start with the form id and a NUL so a prefix
+// scan yields one form's documents already in revision / creation order.
+type BoltStore struct {
+	DB *bolt.DB
+}
+
+func NewBoltStore() (*BoltStore, error) {
+	path := os.Getenv("BOLT_PATH")
+	if path == "" {
+		path = "formbuilder.db"
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{bucketForms, bucketRevisions, bucketResponses, bucketUsers, bucketEmails, bucketWorkspaces, bucketUploads} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	log.Printf("opened bolt store: %s", path)
+	return &BoltStore{DB: db}, nil
+}
+
+func (s *BoltStore) Close(ctx context.Context) error {
+	return s.DB.Close()
+}
+
+func (s *BoltStore) CreateForm(ctx context.Context, f *models.Form) error {
+	b, err := bson.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucketForms)
+		if bkt.Get([]byte(f.ID)) != nil {
+			return ErrDuplicate
+		}
+		return bkt.Put([]byte(f.ID), b)
+	})
+}
+
+func (s *BoltStore) GetForm(ctx context.Context, id string) (*models.Form, error) {
+	var f models.Form
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		return getDoc(tx.Bucket(bucketForms), []byte(id), &f)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (s *BoltStore) UpdateForm(ctx context.Context, f *models.Form, expectedRevision int) error {
+	b, err := bson.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucketForms)
+		cur := bkt.Get([]byte(f.ID))
+		if cur == nil {
+			return ErrNotFound
+		}
+		if err := checkRevision(cur, expectedRevision); err != nil {
+			return err
+		}
+		return bkt.Put([]byte(f.ID), b)
+	})
+}
+
+func (s *BoltStore) ListForms(ctx context.Context, q FormQuery) ([]models.Form, error) {
+	var out []models.Form
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketForms).ForEach(func(k, v []byte) error {
+			var f models.Form
+			if err := bson.Unmarshal(v, &f); err != nil {
+				return err
+			}
+			if q.Match(&f) {
+				out = append(out, f)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	return out, nil
+}
+
+func (s *BoltStore) DeleteForm(ctx context.Context, id string) error {
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		forms := tx.Bucket(bucketForms)
+		if forms.Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+		if err := forms.Delete([]byte(id)); err != nil {
+			return err
+		}
+		_, err := deletePrefix(tx.Bucket(bucketRevisions), formPrefix(id))
+		return err
+	})
+}
+
+func (s *BoltStore) CreateRevision(ctx context.Context, r *models.FormRevision) error {
+	r.ID = RevisionID(r.FormID, r.Revision)
+	b, err := bson.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucketRevisions)
+		key := revisionKey(r.FormID, r.Revision)
+		if bkt.Get(key) != nil {
+			return ErrDuplicate
+		}
+		return bkt.Put(key, b)
+	})
+}
+
+func (s *BoltStore) ListRevisions(ctx context.Context, formID string) ([]models.FormRevision, error) {
+	var out []models.FormRevision
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		return scanPrefix(tx.Bucket(bucketRevisions), formPrefix(formID), func(v []byte) error {
+			var r models.FormRevision
+			if err := bson.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			out = append(out, r)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *BoltStore) GetRevision(ctx context.Context, formID string, revision int) (*models.FormRevision, error) {
+	var r models.FormRevision
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		return getDoc(tx.Bucket(bucketRevisions), revisionKey(formID, revision), &r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *BoltStore) CreateResponse(ctx context.Context, r *models.Response) error {
+	b, err := bson.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketResponses).Put(responseKey(r), b)
+	})
+}
+
+func (s *BoltStore) ListResponses(ctx context.Context, formID string) ([]models.Response, error) {
+	var out []models.Response
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		return scanPrefix(tx.Bucket(bucketResponses), formPrefix(formID), func(v []byte) error {
+			var r models.Response
+			if err := bson.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			out = append(out, r)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *BoltStore) DeleteResponses(ctx context.Context, formID string) (int, error) {
+	var n int
+	err := s.DB.Update(func(tx *bolt.Tx) error {
+		var err error
+		n, err = deletePrefix(tx.Bucket(bucketResponses), formPrefix(formID))
+		return err
+	})
+	return n, err
+}
+
+func (s *BoltStore) AnonymizeResponses(ctx context.Context, formID string) (int, error) {
+	var n int
+	err := s.DB.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucketResponses)
+		prefix := formPrefix(formID)
+		updated := map[string][]byte{}
+		c := bkt.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var r models.Response
+			if err := bson.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			r.UserID = ""
+			b, err := bson.Marshal(&r)
+			if err != nil {
+				return err
+			}
+			updated[string(k)] = b
+		}
+		// writing while the cursor is live would invalidate it
+		for k, b := range updated {
+			if err := bkt.Put([]byte(k), b); err != nil {
+				return err
+			}
+		}
+		n = len(updated)
+		return nil
+	})
+	return n, err
+}
+
+func (s *BoltStore) CreateUser(ctx context.Context, u *models.User) error {
+	b, err := bson.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		users := tx.Bucket(bucketUsers)
+		emails := tx.Bucket(bucketEmails)
+		if users.Get([]byte(u.ID)) != nil || emails.Get([]byte(u.Email)) != nil {
+			return ErrDuplicate
+		}
+		if err := users.Put([]byte(u.ID), b); err != nil {
+			return err
+		}
+		return emails.Put([]byte(u.Email), []byte(u.ID))
+	})
+}
+
+func (s *BoltStore) GetUser(ctx context.Context, id string) (*models.User, error) {
+	var u models.User
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		return getDoc(tx.Bucket(bucketUsers), []byte(id), &u)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *BoltStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var u models.User
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		id := tx.Bucket(bucketEmails).Get([]byte(email))
+		if id == nil {
+			return ErrNotFound
+		}
+		return getDoc(tx.Bucket(bucketUsers), id, &u)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *BoltStore) CreateWorkspace(ctx context.Context, w *models.Workspace) error {
+	b, err := bson.Marshal(w)
+	if err != nil {
+		return err
+	}
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucketWorkspaces)
+		if bkt.Get([]byte(w.ID)) != nil {
+			return ErrDuplicate
+		}
+		return bkt.Put([]byte(w.ID), b)
+	})
+}
+
+func (s *BoltStore) GetWorkspace(ctx context.Context, id string) (*models.Workspace, error) {
+	var w models.Workspace
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		return getDoc(tx.Bucket(bucketWorkspaces), []byte(id), &w)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (s *BoltStore) UpdateWorkspace(ctx context.Context, w *models.Workspace, expectedRevision int) error {
+	b, err := bson.Marshal(w)
+	if err != nil {
+		return err
+	}
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucketWorkspaces)
+		cur := bkt.Get([]byte(w.ID))
+		if cur == nil {
+			return ErrNotFound
+		}
+		if err := checkRevision(cur, expectedRevision); err != nil {
+			return err
+		}
+		return bkt.Put([]byte(w.ID), b)
+	})
+}
+
+func (s *BoltStore) ListWorkspaces(ctx context.Context, userID string) ([]models.Workspace, error) {
+	var out []models.Workspace
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketWorkspaces).ForEach(func(k, v []byte) error {
+			var w models.Workspace
+			if err := bson.Unmarshal(v, &w); err != nil {
+				return err
+			}
+			if isWorkspaceMember(&w, userID) {
+				out = append(out, w)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func (s *BoltStore) DeleteWorkspace(ctx context.Context, id string) error {
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucketWorkspaces)
+		if bkt.Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+		return bkt.Delete([]byte(id))
+	})
+}
+
+func (s *BoltStore) CreateUpload(ctx context.Context, u *models.Upload) error {
+	b, err := bson.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucketUploads)
+		if bkt.Get([]byte(u.ID)) != nil {
+			return ErrDuplicate
+		}
+		return bkt.Put([]byte(u.ID), b)
+	})
+}
+
+func (s *BoltStore) GetUpload(ctx context.Context, id string) (*models.Upload, error) {
+	var u models.Upload
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		return getDoc(tx.Bucket(bucketUploads), []byte(id), &u)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *BoltStore) AttachUpload(ctx context.Context, id, responseID string) error {
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucketUploads)
+		var u models.Upload
+		if err := getDoc(bkt, []byte(id), &u); err != nil {
+			return err
+		}
+		if u.ResponseID != "" {
+			return ErrConflict
+		}
+		u.ResponseID = responseID
+		b, err := bson.Marshal(&u)
+		if err != nil {
+			return err
+		}
+		return bkt.Put([]byte(id), b)
+	})
+}
+
+func (s *BoltStore) DeleteUploads(ctx context.Context, formID string) ([]models.Upload, error) {
+	return s.deleteUploads(func(u *models.Upload) bool { return u.FormID == formID })
+}
+
+func (s *BoltStore) DeleteStaleUploads(ctx context.Context, before int64) ([]models.Upload, error) {
+	return s.deleteUploads(func(u *models.Upload) bool { return u.ResponseID == "" && u.Created < before })
+}
+
+// deleteUploads scans the uploads bucket, which is keyed by upload id only.
+func (s *BoltStore) deleteUploads(match func(*models.Upload) bool) ([]models.Upload, error) {
+	var out []models.Upload
+	err := s.DB.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucketUploads)
+		var keys [][]byte
+		err := bkt.ForEach(func(k, v []byte) error {
+			var u models.Upload
+			if err := bson.Unmarshal(v, &u); err != nil {
+				return err
+			}
+			if match(&u) {
+				keys = append(keys, append([]byte(nil), k...))
+				out = append(out, u)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// deleting inside ForEach would invalidate the iteration
+		for _, k := range keys {
+			if err := bkt.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func getDoc(bkt *bolt.Bucket, key []byte, out interface{}) error {
+	v := bkt.Get(key)
+	if v == nil {
+		return ErrNotFound
+	}
+	return bson.Unmarshal(v, out)
+}
+
+func scanPrefix(bkt *bolt.Bucket, prefix []byte, fn func(v []byte) error) error {
+	c := bkt.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deletePrefix(bkt *bolt.Bucket, prefix []byte) (int, error) {
+	var keys [][]byte
+	c := bkt.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	for _, k := range keys {
+		if err := bkt.Delete(k); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
+func formPrefix(formID string) []byte {
+	return []byte(formID + "\x00")
+}
+
+func revisionKey(formID string, revision int) []byte {
+	return []byte(fmt.Sprintf("%s\x00%010d", formID, revision))
+}
+
+func responseKey(r *models.Response) []byte {
+	return []byte(fmt.Sprintf("%s\x00%020d\x00%s", r.FormID, r.Created, r.ID))
+}
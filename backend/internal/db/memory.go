@@ -0,0 +1,421 @@
+package db
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+// MemoryStore keeps every document BSON-encoded in process memory. Encoding on
+// the way in and out isolates callers from each other and makes decoded values
+// look exactly like the ones MongoStore returns.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	forms      map[string][]byte
+	revisions  map[string][][]byte
+	responses  map[string][][]byte
+	users      map[string][]byte
+	emails     map[string]string
+	workspaces map[string][]byte
+	uploads    map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		forms:      make(map[string][]byte),
+		revisions:  make(map[string][][]byte),
+		responses:  make(map[string][][]byte),
+		users:      make(map[string][]byte),
+		emails:     make(map[string]string),
+		workspaces: make(map[string][]byte),
+		uploads:    make(map[string][]byte),
+	}
+}
+
+func (s *MemoryStore) Close(ctx context.Context) error { return nil }
+
+func (s *MemoryStore) CreateForm(ctx context.Context, f *models.Form) error {
+	b, err := bson.Marshal(f)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.forms[f.ID]; ok {
+		return ErrDuplicate
+	}
+	s.forms[f.ID] = b
+	return nil
+}
+
+func (s *MemoryStore) GetForm(ctx context.Context, id string) (*models.Form, error) {
+	s.mu.RLock()
+	b, ok := s.forms[id]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var f models.Form
+	if err := bson.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (s *MemoryStore) UpdateForm(ctx context.Context, f *models.Form, expectedRevision int) error {
+	b, err := bson.Marshal(f)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.forms[f.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := checkRevision(cur, expectedRevision); err != nil {
+		return err
+	}
+	s.forms[f.ID] = b
+	return nil
+}
+
+func (s *MemoryStore) ListForms(ctx context.Context, q FormQuery) ([]models.Form, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []models.Form
+	for _, b := range s.forms {
+		var f models.Form
+		if err := bson.Unmarshal(b, &f); err != nil {
+			return nil, err
+		}
+		if q.Match(&f) {
+			out = append(out, f)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	return out, nil
+}
+
+func (s *MemoryStore) DeleteForm(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.forms[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.forms, id)
+	delete(s.revisions, id)
+	return nil
+}
+
+func (s *MemoryStore) CreateRevision(ctx context.Context, r *models.FormRevision) error {
+	r.ID = RevisionID(r.FormID, r.Revision)
+	b, err := bson.Marshal(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, doc := range s.revisions[r.FormID] {
+		if bson.Raw(doc).Lookup("_id").StringValue() == r.ID {
+			return ErrDuplicate
+		}
+	}
+	s.revisions[r.FormID] = append(s.revisions[r.FormID], b)
+	return nil
+}
+
+func (s *MemoryStore) ListRevisions(ctx context.Context, formID string) ([]models.FormRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	docs := s.revisions[formID]
+	out := make([]models.FormRevision, 0, len(docs))
+	for _, b := range docs {
+		var r models.FormRevision
+		if err := bson.Unmarshal(b, &r); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Revision < out[j].Revision })
+	return out, nil
+}
+
+func (s *MemoryStore) GetRevision(ctx context.Context, formID string, revision int) (*models.FormRevision, error) {
+	revs, err := s.ListRevisions(ctx, formID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range revs {
+		if revs[i].Revision == revision {
+			return &revs[i], nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) CreateResponse(ctx context.Context, r *models.Response) error {
+	b, err := bson.Marshal(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[r.FormID] = append(s.responses[r.FormID], b)
+	return nil
+}
+
+func (s *MemoryStore) ListResponses(ctx context.Context, formID string) ([]models.Response, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	docs := s.responses[formID]
+	out := make([]models.Response, 0, len(docs))
+	for _, b := range docs {
+		var r models.Response
+		if err := bson.Unmarshal(b, &r); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Created < out[j].Created })
+	return out, nil
+}
+
+func (s *MemoryStore) DeleteResponses(ctx context.Context, formID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.responses[formID])
+	delete(s.responses, formID)
+	return n, nil
+}
+
+func (s *MemoryStore) AnonymizeResponses(ctx context.Context, formID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	docs := s.responses[formID]
+	for i, b := range docs {
+		var r models.Response
+		if err := bson.Unmarshal(b, &r); err != nil {
+			return i, err
+		}
+		r.UserID = ""
+		nb, err := bson.Marshal(&r)
+		if err != nil {
+			return i, err
+		}
+		docs[i] = nb
+	}
+	return len(docs), nil
+}
+
+func (s *MemoryStore) CreateUser(ctx context.Context, u *models.User) error {
+	b, err := bson.Marshal(u)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[u.ID]; ok {
+		return ErrDuplicate
+	}
+	if _, ok := s.emails[u.Email]; ok {
+		return ErrDuplicate
+	}
+	s.users[u.ID] = b
+	s.emails[u.Email] = u.ID
+	return nil
+}
+
+func (s *MemoryStore) GetUser(ctx context.Context, id string) (*models.User, error) {
+	s.mu.RLock()
+	b, ok := s.users[id]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var u models.User
+	if err := bson.Unmarshal(b, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *MemoryStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	s.mu.RLock()
+	id, ok := s.emails[email]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s.GetUser(ctx, id)
+}
+
+func (s *MemoryStore) CreateWorkspace(ctx context.Context, w *models.Workspace) error {
+	b, err := bson.Marshal(w)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.workspaces[w.ID]; ok {
+		return ErrDuplicate
+	}
+	s.workspaces[w.ID] = b
+	return nil
+}
+
+func (s *MemoryStore) GetWorkspace(ctx context.Context, id string) (*models.Workspace, error) {
+	s.mu.RLock()
+	b, ok := s.workspaces[id]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var w models.Workspace
+	if err := bson.Unmarshal(b, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (s *MemoryStore) UpdateWorkspace(ctx context.Context, w *models.Workspace, expectedRevision int) error {
+	b, err := bson.Marshal(w)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.workspaces[w.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := checkRevision(cur, expectedRevision); err != nil {
+		return err
+	}
+	s.workspaces[w.ID] = b
+	return nil
+}
+
+func (s *MemoryStore) ListWorkspaces(ctx context.Context, userID string) ([]models.Workspace, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []models.Workspace
+	for _, b := range s.workspaces {
+		var w models.Workspace
+		if err := bson.Unmarshal(b, &w); err != nil {
+			return nil, err
+		}
+		if isWorkspaceMember(&w, userID) {
+			out = append(out, w)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func (s *MemoryStore) DeleteWorkspace(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.workspaces[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.workspaces, id)
+	return nil
+}
+
+func (s *MemoryStore) CreateUpload(ctx context.Context, u *models.Upload) error {
+	b, err := bson.Marshal(u)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.uploads[u.ID]; ok {
+		return ErrDuplicate
+	}
+	s.uploads[u.ID] = b
+	return nil
+}
+
+func (s *MemoryStore) GetUpload(ctx context.Context, id string) (*models.Upload, error) {
+	s.mu.RLock()
+	b, ok := s.uploads[id]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var u models.Upload
+	if err := bson.Unmarshal(b, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *MemoryStore) AttachUpload(ctx context.Context, id, responseID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.uploads[id]
+	if !ok {
+		return ErrNotFound
+	}
+	var u models.Upload
+	if err := bson.Unmarshal(b, &u); err != nil {
+		return err
+	}
+	if u.ResponseID != "" {
+		return ErrConflict
+	}
+	u.ResponseID = responseID
+	nb, err := bson.Marshal(&u)
+	if err != nil {
+		return err
+	}
+	s.uploads[id] = nb
+	return nil
+}
+
+func checkRevision(doc []byte, expected int) error {
+	var cur struct {
+		Revision int `bson:"revision"`
+	}
+	if err := bson.Unmarshal(doc, &cur); err != nil {
+		return err
+	}
+	if cur.Revision != expected {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeleteUploads(ctx context.Context, formID string) ([]models.Upload, error) {
+	return s.deleteUploads(func(u *models.Upload) bool { return u.FormID == formID })
+}
+
+func (s *MemoryStore) DeleteStaleUploads(ctx context.Context, before int64) ([]models.Upload, error) {
+	return s.deleteUploads(func(u *models.Upload) bool { return u.ResponseID == "" && u.Created < before })
+}
+
+func (s *MemoryStore) deleteUploads(match func(*models.Upload) bool) ([]models.Upload, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []models.Upload
+	for id, b := range s.uploads {
+		var u models.Upload
+		if err := bson.Unmarshal(b, &u); err != nil {
+			return out, err
+		}
+		if match(&u) {
+			delete(s.uploads, id)
+			out = append(out, u)
+		}
+	}
+	return out, nil
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
 	"time"
@@ -9,14 +10,19 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
 )
 
 type MongoStore struct {
-	Client    *mongo.Client
-	DB        *mongo.Database
-	Forms     *mongo.Collection
-	Responses *mongo.Collection
-	Users     *mongo.Collection
+	Client     *mongo.Client
+	DB         *mongo.Database
+	Forms      *mongo.Collection
+	Revisions  *mongo.Collection
+	Responses  *mongo.Collection
+	Users      *mongo.Collection
+	Workspaces *mongo.Collection
+	Uploads    *mongo.Collection
 }
 
 func NewMongoStore() (*MongoStore, error) {
@@ -42,11 +48,14 @@ func NewMongoStore() (*MongoStore, error) {
 
 	db := client.Database(dbName)
 	store := &MongoStore{
-		Client:    client,
-		DB:        db,
-		Forms:     db.Collection("forms"),
-		Responses: db.Collection("responses"),
-		Users:     db.Collection("users"),
+		Client:     client,
+		DB:         db,
+		Forms:      db.Collection("forms"),
+		Revisions:  db.Collection("form_revisions"),
+		Responses:  db.Collection("responses"),
+		Users:      db.Collection("users"),
+		Workspaces: db.Collection("workspaces"),
+		Uploads:    db.Collection("uploads"),
 	}
 
 	_, _ = store.Forms.Indexes().CreateOne(ctx, mongo.IndexModel{
@@ -58,6 +67,21 @@ func NewMongoStore() (*MongoStore, error) {
 		Options: options.Index().SetBackground(true),
 	})
 
+	_, _ = store.Forms.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "collaborators.userId", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	})
+
+	_, _ = store.Forms.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "workspaceId", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	})
+
+	_, _ = store.Revisions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "formId", Value: 1}, {Key: "revision", Value: 1}},
+		Options: options.Index().SetUnique(true).SetBackground(true),
+	})
+
 	_, _ = store.Responses.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys:    bson.D{{Key: "formId", Value: 1}, {Key: "created", Value: -1}},
 		Options: options.Index().SetBackground(true),
@@ -68,6 +92,351 @@ func NewMongoStore() (*MongoStore, error) {
 		Options: options.Index().SetUnique(true).SetBackground(true),
 	})
 
+	_, _ = store.Workspaces.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "members.userId", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	})
+
+	_, _ = store.Uploads.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "formId", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	})
+
+	_, _ = store.Uploads.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	})
+
 	log.Printf("connected to MongoDB: %s / db: %s", uri, dbName)
 	return store, nil
 }
+
+func (s *MongoStore) Close(ctx context.Context) error {
+	return s.Client.Disconnect(ctx)
+}
+
+func (s *MongoStore) CreateForm(ctx context.Context, f *models.Form) error {
+	_, err := s.Forms.InsertOne(ctx, f)
+	return mapErr(err)
+}
+
+func (s *MongoStore) GetForm(ctx context.Context, id string) (*models.Form, error) {
+	var f models.Form
+	if err := s.Forms.FindOne(ctx, bson.M{"_id": id}).Decode(&f); err != nil {
+		return nil, mapErr(err)
+	}
+	return &f, nil
+}
+
+func (s *MongoStore) UpdateForm(ctx context.Context, f *models.Form, expectedRevision int) error {
+	var rev interface{} = expectedRevision
+	if expectedRevision == 0 {
+		// forms saved before revisions existed have no revision key at all
+		rev = bson.M{"$in": bson.A{0, nil}}
+	}
+	res, err := s.Forms.ReplaceOne(ctx, bson.M{"_id": f.ID, "revision": rev}, f)
+	if err != nil {
+		return mapErr(err)
+	}
+	if res.MatchedCount == 0 {
+		if n, _ := s.Forms.CountDocuments(ctx, bson.M{"_id": f.ID}); n > 0 {
+			return ErrConflict
+		}
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) ListForms(ctx context.Context, q FormQuery) ([]models.Form, error) {
+	who := bson.M{}
+	if q.OwnerID != "" {
+		who["ownerId"] = q.OwnerID
+	}
+	if q.MemberID != "" {
+		who["$or"] = bson.A{
+			bson.M{"ownerId": q.MemberID},
+			bson.M{"collaborators.userId": q.MemberID},
+		}
+	}
+	filter := who
+	if len(q.WorkspaceIDs) > 0 {
+		filter = bson.M{"$or": bson.A{
+			who,
+			bson.M{"workspaceId": bson.M{"$in": q.WorkspaceIDs}},
+		}}
+	}
+	if q.WorkspaceID != "" {
+		filter["workspaceId"] = q.WorkspaceID
+	}
+	if q.Template != "" {
+		filter["template"] = q.Template
+	}
+	if q.Trashed {
+		filter["deletedAt"] = bson.M{"$gt": 0}
+	} else {
+		filter["deletedAt"] = bson.M{"$not": bson.M{"$gt": 0}}
+	}
+
+	cur, err := s.Forms.Find(ctx, filter, &options.FindOptions{
+		Sort: bson.M{"_id": -1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []models.Form
+	for cur.Next(ctx) {
+		var f models.Form
+		if err := cur.Decode(&f); err == nil {
+			out = append(out, f)
+		}
+	}
+	return out, cur.Err()
+}
+
+func (s *MongoStore) DeleteForm(ctx context.Context, id string) error {
+	res, err := s.Forms.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	_, err = s.Revisions.DeleteMany(ctx, bson.M{"formId": id})
+	return err
+}
+
+func (s *MongoStore) CreateRevision(ctx context.Context, r *models.FormRevision) error {
+	r.ID = RevisionID(r.FormID, r.Revision)
+	_, err := s.Revisions.InsertOne(ctx, r)
+	return mapErr(err)
+}
+
+func (s *MongoStore) ListRevisions(ctx context.Context, formID string) ([]models.FormRevision, error) {
+	cur, err := s.Revisions.Find(ctx, bson.M{"formId": formID}, &options.FindOptions{
+		Sort: bson.M{"revision": 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []models.FormRevision
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *MongoStore) GetRevision(ctx context.Context, formID string, revision int) (*models.FormRevision, error) {
+	var r models.FormRevision
+	if err := s.Revisions.FindOne(ctx, bson.M{"formId": formID, "revision": revision}).Decode(&r); err != nil {
+		return nil, mapErr(err)
+	}
+	return &r, nil
+}
+
+func (s *MongoStore) CreateResponse(ctx context.Context, r *models.Response) error {
+	_, err := s.Responses.InsertOne(ctx, r)
+	return mapErr(err)
+}
+
+func (s *MongoStore) ListResponses(ctx context.Context, formID string) ([]models.Response, error) {
+	cur, err := s.Responses.Find(ctx, bson.M{"formId": formID}, &options.FindOptions{
+		Sort: bson.M{"created": 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []models.Response
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *MongoStore) DeleteResponses(ctx context.Context, formID string) (int, error) {
+	res, err := s.Responses.DeleteMany(ctx, bson.M{"formId": formID})
+	if err != nil {
+		return 0, err
+	}
+	return int(res.DeletedCount), nil
+}
+
+func (s *MongoStore) AnonymizeResponses(ctx context.Context, formID string) (int, error) {
+	res, err := s.Responses.UpdateMany(ctx, bson.M{"formId": formID}, bson.M{"$unset": bson.M{"userId": ""}})
+	if err != nil {
+		return 0, err
+	}
+	return int(res.MatchedCount), nil
+}
+
+func (s *MongoStore) CreateUser(ctx context.Context, u *models.User) error {
+	_, err := s.Users.InsertOne(ctx, u)
+	return mapErr(err)
+}
+
+func (s *MongoStore) GetUser(ctx context.Context, id string) (*models.User, error) {
+	var u models.User
+	if err := s.Users.FindOne(ctx, bson.M{"_id": id}).Decode(&u); err != nil {
+		return nil, mapErr(err)
+	}
+	return &u, nil
+}
+
+func (s *MongoStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var u models.User
+	if err := s.Users.FindOne(ctx, bson.M{"email": email}).Decode(&u); err != nil {
+		return nil, mapErr(err)
+	}
+	return &u, nil
+}
+
+func (s *MongoStore) CreateWorkspace(ctx context.Context, w *models.Workspace) error {
+	_, err := s.Workspaces.InsertOne(ctx, w)
+	return mapErr(err)
+}
+
+func (s *MongoStore) GetWorkspace(ctx context.Context, id string) (*models.Workspace, error) {
+	var w models.Workspace
+	if err := s.Workspaces.FindOne(ctx, bson.M{"_id": id}).Decode(&w); err != nil {
+		return nil, mapErr(err)
+	}
+	return &w, nil
+}
+
+func (s *MongoStore) UpdateWorkspace(ctx context.Context, w *models.Workspace, expectedRevision int) error {
+	var rev interface{} = expectedRevision
+	if expectedRevision == 0 {
+		// workspaces saved before revisions existed have no revision key
+		rev = bson.M{"$in": bson.A{0, nil}}
+	}
+	res, err := s.Workspaces.ReplaceOne(ctx, bson.M{"_id": w.ID, "revision": rev}, w)
+	if err != nil {
+		return mapErr(err)
+	}
+	if res.MatchedCount == 0 {
+		if n, _ := s.Workspaces.CountDocuments(ctx, bson.M{"_id": w.ID}); n > 0 {
+			return ErrConflict
+		}
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) ListWorkspaces(ctx context.Context, userID string) ([]models.Workspace, error) {
+	cur, err := s.Workspaces.Find(ctx, bson.M{"members.userId": userID}, &options.FindOptions{
+		Sort: bson.M{"name": 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []models.Workspace
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *MongoStore) DeleteWorkspace(ctx context.Context, id string) error {
+	res, err := s.Workspaces.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) CreateUpload(ctx context.Context, u *models.Upload) error {
+	_, err := s.Uploads.InsertOne(ctx, u)
+	return mapErr(err)
+}
+
+func (s *MongoStore) GetUpload(ctx context.Context, id string) (*models.Upload, error) {
+	var u models.Upload
+	if err := s.Uploads.FindOne(ctx, bson.M{"_id": id}).Decode(&u); err != nil {
+		return nil, mapErr(err)
+	}
+	return &u, nil
+}
+
+func (s *MongoStore) AttachUpload(ctx context.Context, id, responseID string) error {
+	res, err := s.Uploads.UpdateOne(ctx,
+		bson.M{"_id": id, "responseId": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"responseId": responseID}},
+	)
+	if err != nil {
+		return mapErr(err)
+	}
+	if res.MatchedCount == 0 {
+		if _, err := s.GetUpload(ctx, id); err != nil {
+			return err
+		}
+		return ErrConflict
+	}
+	return nil
+}
+
+func (s *MongoStore) DeleteUploads(ctx context.Context, formID string) ([]models.Upload, error) {
+	cur, err := s.Uploads.Find(ctx, bson.M{"formId": formID})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []models.Upload
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	if _, err := s.Uploads.DeleteMany(ctx, bson.M{"formId": formID}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteStaleUploads deletes one upload at a time so that one attached in
+// the meantime is kept and not reported.
+func (s *MongoStore) DeleteStaleUploads(ctx context.Context, before int64) ([]models.Upload, error) {
+	unattached := bson.M{"$exists": false}
+	cur, err := s.Uploads.Find(ctx, bson.M{"responseId": unattached, "created": bson.M{"$lt": before}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var found []models.Upload
+	if err := cur.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	var out []models.Upload
+	for _, u := range found {
+		res, err := s.Uploads.DeleteOne(ctx, bson.M{"_id": u.ID, "responseId": unattached})
+		if err != nil {
+			return out, err
+		}
+		if res.DeletedCount == 1 {
+			out = append(out, u)
+		}
+	}
+	return out, nil
+}
+
+func mapErr(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return ErrDuplicate
+	default:
+		return err
+	}
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrDuplicate = errors.New("duplicate key")
+	ErrConflict  = errors.New("version conflict")
+)
+
+// Store is the persistence boundary used by the HTTP handlers. Every backend
+// (Mongo, bolt, in-memory) must return ErrNotFound for missing documents,
+// ErrDuplicate for unique-key violations and ErrConflict for lost optimistic
+// updates so handlers can map them to statuses.
+type Store interface {
+	FormRepository
+	RevisionRepository
+	ResponseRepository
+	UserRepository
+	WorkspaceRepository
+	UploadRepository
+	Close(ctx context.Context) error
+}
+
+type FormRepository interface {
+	CreateForm(ctx context.Context, f *models.Form) error
+	GetForm(ctx context.Context, id string) (*models.Form, error)
+	// UpdateForm replaces the stored document with f only if its revision is
+	// still expectedRevision, otherwise it returns ErrConflict.
+	UpdateForm(ctx context.Context, f *models.Form, expectedRevision int) error
+	// ListForms returns the forms matching q, newest id first.
+	ListForms(ctx context.Context, q FormQuery) ([]models.Form, error)
+	// DeleteForm permanently removes a form and its revision history.
+	DeleteForm(ctx context.Context, id string) error
+}
+
+// FormQuery selects forms for listings. Empty strings match anything;
+// MemberID matches the owner or any collaborator; WorkspaceIDs widens
+// OwnerID/MemberID so forms owned by any of those workspaces match too;
+// WorkspaceID restricts the result to one workspace; Trashed picks
+// soft-deleted forms instead of live ones.
+type FormQuery struct {
+	OwnerID      string
+	MemberID     string
+	WorkspaceIDs []string
+	WorkspaceID  string
+	Template     string
+	Trashed      bool
+}
+
+// Match is the in-process equivalent of the Mongo filter built from q; the
+// memory and bolt backends scan with it.
+func (q FormQuery) Match(f *models.Form) bool {
+	personal := (q.OwnerID == "" || f.OwnerID == q.OwnerID) &&
+		(q.MemberID == "" || isMember(f, q.MemberID))
+	if !personal && (f.WorkspaceID == "" || !containsString(q.WorkspaceIDs, f.WorkspaceID)) {
+		return false
+	}
+	if q.WorkspaceID != "" && f.WorkspaceID != q.WorkspaceID {
+		return false
+	}
+	if q.Template != "" && f.Template != q.Template {
+		return false
+	}
+	return (f.DeletedAt != 0) == q.Trashed
+}
+
+type RevisionRepository interface {
+	CreateRevision(ctx context.Context, r *models.FormRevision) error
+	// ListRevisions returns every snapshot of a form, oldest first.
+	ListRevisions(ctx context.Context, formID string) ([]models.FormRevision, error)
+	GetRevision(ctx context.Context, formID string, revision int) (*models.FormRevision, error)
+}
+
+type ResponseRepository interface {
+	CreateResponse(ctx context.Context, r *models.Response) error
+	// ListResponses returns every response of a form ordered by creation time.
+	ListResponses(ctx context.Context, formID string) ([]models.Response, error)
+	DeleteResponses(ctx context.Context, formID string) (int, error)
+	// AnonymizeResponses strips respondent identity but keeps the answers.
+	AnonymizeResponses(ctx context.Context, formID string) (int, error)
+}
+
+type UserRepository interface {
+	CreateUser(ctx context.Context, u *models.User) error
+	GetUser(ctx context.Context, id string) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+}
+
+type WorkspaceRepository interface {
+	CreateWorkspace(ctx context.Context, w *models.Workspace) error
+	GetWorkspace(ctx context.Context, id string) (*models.Workspace, error)
+	// UpdateWorkspace replaces the stored workspace with w only if its
+	// revision is still expectedRevision, otherwise it returns ErrConflict.
+	UpdateWorkspace(ctx context.Context, w *models.Workspace, expectedRevision int) error
+	// ListWorkspaces returns the workspaces userID is a member of, by name.
+	ListWorkspaces(ctx context.Context, userID string) ([]models.Workspace, error)
+	DeleteWorkspace(ctx context.Context, id string) error
+}
+
+type UploadRepository interface {
+	CreateUpload(ctx context.Context, u *models.Upload) error
+	GetUpload(ctx context.Context, id string) (*models.Upload, error)
+	// AttachUpload records the response an upload was submitted with. It
+	// returns ErrConflict if the upload already belongs to a response.
+	AttachUpload(ctx context.Context, id, responseID string) error
+	// DeleteUploads removes every upload record of a form and returns them
+	// so the caller can remove their contents.
+	DeleteUploads(ctx context.Context, formID string) ([]models.Upload, error)
+	// DeleteStaleUploads removes the uploads created before the Unix time
+	// before that were never attached to a response, and returns them.
+	DeleteStaleUploads(ctx context.Context, before int64) ([]models.Upload, error)
+}
+
+// Open builds the store selected by STORAGE_DRIVER ("mongo" by default,
+// "bolt" for the embedded single-file store, "memory" for throwaway runs).
+func Open() (Store, error) {
+	switch driver := os.Getenv("STORAGE_DRIVER"); driver {
+	case "", "mongo":
+		s, err := NewMongoStore()
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "bolt":
+		s, err := NewBoltStore()
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "memory":
+		log.Printf("using in-memory storage; data is lost on restart")
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", driver)
+	}
+}
+
+func RevisionID(formID string, revision int) string {
+	return fmt.Sprintf("%s:%d", formID, revision)
+}
+
+func isMember(f *models.Form, userID string) bool {
+	if f.OwnerID == userID {
+		return true
+	}
+	for _, c := range f.Collaborators {
+		if c.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func isWorkspaceMember(w *models.Workspace, userID string) bool {
+	for _, m := range w.Members {
+		if m.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
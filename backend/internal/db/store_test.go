@@ -0,0 +1,243 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+// eachStore runs fn against every backend that needs no external service.
+func eachStore(t *testing.T, fn func(t *testing.T, s Store)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, NewMemoryStore())
+	})
+	t.Run("bolt", func(t *testing.T) {
+		t.Setenv("BOLT_PATH", filepath.Join(t.TempDir(), "test.db"))
+		s, err := NewBoltStore()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close(context.Background())
+		fn(t, s)
+	})
+}
+
+func uploadIDs(ups []models.Upload) []string {
+	ids := make([]string, 0, len(ups))
+	for _, u := range ups {
+		ids = append(ids, u.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestDeleteUploads(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		for _, u := range []models.Upload{
+			{ID: "a1", FormID: "a", Created: 10},
+			{ID: "a2", FormID: "a", Created: 10},
+			{ID: "b1", FormID: "b", Created: 10},
+		} {
+			if err := s.CreateUpload(ctx, &u); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		ups, err := s.DeleteUploads(ctx, "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := uploadIDs(ups); len(got) != 2 || got[0] != "a1" || got[1] != "a2" {
+			t.Errorf("deleted %v, want [a1 a2]", got)
+		}
+		if _, err := s.GetUpload(ctx, "a1"); err != ErrNotFound {
+			t.Errorf("GetUpload(a1) = %v, want ErrNotFound", err)
+		}
+		if _, err := s.GetUpload(ctx, "b1"); err != nil {
+			t.Errorf("other form's upload was deleted: %v", err)
+		}
+	})
+}
+
+func TestDeleteStaleUploads(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		for _, u := range []models.Upload{
+			{ID: "old", FormID: "f", Created: 10},
+			{ID: "old-claimed", FormID: "f", Created: 10},
+			{ID: "new", FormID: "f", Created: 100},
+		} {
+			if err := s.CreateUpload(ctx, &u); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.AttachUpload(ctx, "old-claimed", "r1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.AttachUpload(ctx, "old-claimed", "r2"); err != ErrConflict {
+			t.Errorf("second AttachUpload = %v, want ErrConflict", err)
+		}
+
+		ups, err := s.DeleteStaleUploads(ctx, 50)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := uploadIDs(ups); len(got) != 1 || got[0] != "old" {
+			t.Errorf("deleted %v, want [old]", got)
+		}
+		for _, id := range []string{"old-claimed", "new"} {
+			if _, err := s.GetUpload(ctx, id); err != nil {
+				t.Errorf("%s was deleted: %v", id, err)
+			}
+		}
+	})
+}
+
+func TestUpdateFormChecksRevision(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		f := models.Form{ID: "f", Title: "One", OwnerID: "u", Revision: 1}
+		if err := s.CreateForm(ctx, &f); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.CreateForm(ctx, &f); err != ErrDuplicate {
+			t.Errorf("second CreateForm = %v, want ErrDuplicate", err)
+		}
+
+		f.Title, f.Revision = "Two", 2
+		if err := s.UpdateForm(ctx, &f, 1); err != nil {
+			t.Fatal(err)
+		}
+		stale := f
+		stale.Title, stale.Revision = "Lost", 2
+		if err := s.UpdateForm(ctx, &stale, 1); err != ErrConflict {
+			t.Errorf("stale UpdateForm = %v, want ErrConflict", err)
+		}
+		got, err := s.GetForm(ctx, "f")
+		if err != nil || got.Title != "Two" || got.Revision != 2 {
+			t.Errorf("GetForm = %+v, %v; want the first update", got, err)
+		}
+		missing := models.Form{ID: "nope"}
+		if err := s.UpdateForm(ctx, &missing, 0); err != ErrNotFound {
+			t.Errorf("UpdateForm(missing) = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestUpdateWorkspaceChecksRevision(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		w := models.Workspace{ID: "w", Name: "One"}
+		if err := s.CreateWorkspace(ctx, &w); err != nil {
+			t.Fatal(err)
+		}
+
+		w.Name, w.Revision = "Two", 1
+		if err := s.UpdateWorkspace(ctx, &w, 0); err != nil {
+			t.Fatal(err)
+		}
+		stale := w
+		stale.Name, stale.Revision = "Lost", 1
+		if err := s.UpdateWorkspace(ctx, &stale, 0); err != ErrConflict {
+			t.Errorf("stale UpdateWorkspace = %v, want ErrConflict", err)
+		}
+		got, err := s.GetWorkspace(ctx, "w")
+		if err != nil || got.Name != "Two" || got.Revision != 1 {
+			t.Errorf("GetWorkspace = %+v, %v; want the first update", got, err)
+		}
+		missing := models.Workspace{ID: "nope"}
+		if err := s.UpdateWorkspace(ctx, &missing, 0); err != ErrNotFound {
+			t.Errorf("UpdateWorkspace(missing) = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestRevisions(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		f := models.Form{ID: "f", Title: "Form", OwnerID: "u", Revision: 1}
+		if err := s.CreateForm(ctx, &f); err != nil {
+			t.Fatal(err)
+		}
+		for _, rev := range []int{2, 1, 3} {
+			r := models.FormRevision{FormID: "f", Revision: rev, Title: "v"}
+			if err := s.CreateRevision(ctx, &r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.CreateRevision(ctx, &models.FormRevision{FormID: "f", Revision: 2}); err != ErrDuplicate {
+			t.Errorf("duplicate CreateRevision = %v, want ErrDuplicate", err)
+		}
+
+		revs, err := s.ListRevisions(ctx, "f")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(revs) != 3 || revs[0].Revision != 1 || revs[2].Revision != 3 {
+			t.Errorf("ListRevisions = %+v, want 1..3 oldest first", revs)
+		}
+		if r, err := s.GetRevision(ctx, "f", 2); err != nil || r.Revision != 2 {
+			t.Errorf("GetRevision(2) = %+v, %v", r, err)
+		}
+		if _, err := s.GetRevision(ctx, "f", 9); err != ErrNotFound {
+			t.Errorf("GetRevision(9) = %v, want ErrNotFound", err)
+		}
+
+		if err := s.DeleteForm(ctx, "f"); err != nil {
+			t.Fatal(err)
+		}
+		if revs, _ := s.ListRevisions(ctx, "f"); len(revs) != 0 {
+			t.Errorf("revisions survived DeleteForm: %+v", revs)
+		}
+	})
+}
+
+func TestListForms(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		for _, f := range []models.Form{
+			{ID: "own", OwnerID: "u"},
+			{ID: "shared", OwnerID: "v", Collaborators: []models.Collaborator{{UserID: "u", Role: models.RoleViewer}}},
+			{ID: "team", OwnerID: "", WorkspaceID: "w"},
+			{ID: "trashed", OwnerID: "u", DeletedAt: 5},
+			{ID: "other", OwnerID: "v", Template: models.TemplatePublic},
+		} {
+			if err := s.CreateForm(ctx, &f); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ids := func(q FormQuery) []string {
+			forms, err := s.ListForms(ctx, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := []string{}
+			for _, f := range forms {
+				out = append(out, f.ID)
+			}
+			sort.Strings(out)
+			return out
+		}
+		tests := []struct {
+			name string
+			q    FormQuery
+			want string
+		}{
+			{"member", FormQuery{MemberID: "u"}, "[own shared]"},
+			{"member and workspace", FormQuery{MemberID: "u", WorkspaceIDs: []string{"w"}}, "[own shared team]"},
+			{"owner trash", FormQuery{OwnerID: "u", Trashed: true}, "[trashed]"},
+			{"workspace", FormQuery{WorkspaceID: "w"}, "[team]"},
+			{"public templates", FormQuery{Template: models.TemplatePublic}, "[other]"},
+		}
+		for _, tt := range tests {
+			if got := fmt.Sprint(ids(tt.q)); got != tt.want {
+				t.Errorf("%s: %s, want %s", tt.name, got, tt.want)
+			}
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func TestCreateFormIgnoresServerManagedFields(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	intruder := s.user("intruder@x.io")
+
+	f := s.createForm(owner, fiber.Map{
+		"title":         "Survey",
+		"collaborators": []fiber.Map{{"userId": intruder, "role": "owner"}},
+		"template":      models.TemplatePublic,
+		"publicResults": true,
+		"deletedAt":     12345,
+	})
+	if len(f.Collaborators) != 0 || f.Template != "" || f.PublicResults || f.DeletedAt != 0 {
+		t.Fatalf("server-managed fields were taken from the request: %+v", f)
+	}
+
+	stored, err := s.store.GetForm(context.Background(), f.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role := roleOf(context.Background(), s.store, stored, intruder); role != "" {
+		t.Errorf("injected collaborator has role %q", role)
+	}
+	if status, _ := s.do("PUT", "/api/forms/"+f.ID+"/collaborators", intruder, fiber.Map{"email": "intruder@x.io", "role": "editor"}); status != fiber.StatusForbidden {
+		t.Errorf("injected collaborator could share the form: %d", status)
+	}
+}
+
+func TestUnknownRoleGrantsNothing(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	other := s.user("other@x.io")
+
+	f := s.createForm(owner, fiber.Map{"title": "Survey"})
+	stored, _ := s.store.GetForm(context.Background(), f.ID)
+	stored.Collaborators = []models.Collaborator{{UserID: other, Role: "superuser"}}
+	if err := s.store.UpdateForm(context.Background(), stored, stored.Revision); err != nil {
+		t.Fatal(err)
+	}
+
+	if role := roleOf(context.Background(), s.store, stored, other); role != "" {
+		t.Errorf("roleOf = %q, want none", role)
+	}
+	if status, _ := s.do("GET", "/api/forms/"+f.ID+"/analytics", other, nil); status != fiber.StatusForbidden {
+		t.Errorf("analytics status = %d, want 403", status)
+	}
+}
+
+func TestGetFormHidesCollaboratorsFromRespondents(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	s.user("viewer@x.io")
+
+	f := s.createForm(owner, fiber.Map{"title": "Survey", "status": "published"})
+	if status, body := s.do("PUT", "/api/forms/"+f.ID+"/collaborators", owner, fiber.Map{"email": "viewer@x.io", "role": "viewer"}); status != fiber.StatusOK {
+		t.Fatalf("share: %d %s", status, body)
+	}
+
+	_, body := s.do("GET", "/api/forms/"+f.ID, "", nil)
+	if got := decode[models.Form](t, body); len(got.Collaborators) != 0 {
+		t.Errorf("anonymous GET lists collaborators: %v", got.Collaborators)
+	}
+	_, body = s.do("GET", "/api/forms/"+f.ID, owner, nil)
+	if got := decode[models.Form](t, body); len(got.Collaborators) != 1 {
+		t.Errorf("owner GET collaborators = %v, want 1", got.Collaborators)
+	}
+}
+
+func TestRolePermissions(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	editor := s.user("editor@x.io")
+	viewer := s.user("viewer@x.io")
+	outsider := s.user("outsider@x.io")
+
+	f := s.createForm(owner, fiber.Map{"title": "Survey"})
+	for email, role := range map[string]string{"editor@x.io": "editor", "viewer@x.io": "viewer"} {
+		if status, body := s.do("PUT", "/api/forms/"+f.ID+"/collaborators", owner, fiber.Map{"email": email, "role": role}); status != fiber.StatusOK {
+			t.Fatalf("share %s: %d %s", role, status, body)
+		}
+	}
+	if status, _ := s.do("PUT", "/api/forms/"+f.ID+"/collaborators", owner, fiber.Map{"email": "outsider@x.io", "role": "owner"}); status != fiber.StatusBadRequest {
+		t.Errorf("sharing as owner: %d, want 400", status)
+	}
+
+	update := fiber.Map{"title": "Renamed"}
+	tests := []struct {
+		name, method, path, user string
+		body                     interface{}
+		want                     int
+	}{
+		{"viewer reads draft", "GET", "/api/forms/" + f.ID, viewer, nil, fiber.StatusOK},
+		{"outsider reads draft", "GET", "/api/forms/" + f.ID, outsider, nil, fiber.StatusForbidden},
+		{"viewer lists revisions", "GET", "/api/forms/" + f.ID + "/revisions", viewer, nil, fiber.StatusOK},
+		{"viewer updates", "PUT", "/api/forms/" + f.ID, viewer, update, fiber.StatusForbidden},
+		{"editor updates", "PUT", "/api/forms/" + f.ID, editor, update, fiber.StatusOK},
+		{"editor shares", "PUT", "/api/forms/" + f.ID + "/collaborators", editor, fiber.Map{"email": "outsider@x.io", "role": "viewer"}, fiber.StatusForbidden},
+		{"editor deletes", "DELETE", "/api/forms/" + f.ID, editor, nil, fiber.StatusForbidden},
+		{"outsider exports", "GET", "/api/forms/" + f.ID + "/export", outsider, nil, fiber.StatusForbidden},
+		{"viewer leaves", "DELETE", "/api/forms/" + f.ID + "/collaborators/" + viewer, viewer, nil, fiber.StatusNoContent},
+		{"viewer after leaving", "GET", "/api/forms/" + f.ID + "/revisions", viewer, nil, fiber.StatusForbidden},
+		{"owner deletes", "DELETE", "/api/forms/" + f.ID, owner, nil, fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		if status, body := s.do(tt.method, tt.path, tt.user, tt.body); status != tt.want {
+			t.Errorf("%s: %d %s, want %d", tt.name, status, body, tt.want)
+		}
+	}
+}
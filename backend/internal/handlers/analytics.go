@@ -7,46 +7,105 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/db"
 	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
 )
 
 type AnalyticsHandler struct {
-	Store *db.MongoStore
+	Store db.Store
 }
 
-func NewAnalyticsHandler(s *db.MongoStore) *AnalyticsHandler { return &AnalyticsHandler{Store: s} }
+func NewAnalyticsHandler(s db.Store) *AnalyticsHandler { return &AnalyticsHandler{Store: s} }
 
+// GetAnalytics serves the aggregate results to the form's members, and to
+// anyone at all once the owner has opted into public results.
 func (h *AnalyticsHandler) GetAnalytics(c *fiber.Ctx) error {
 	formID := c.Params("id")
+	userID, _ := c.Locals("userId").(string)
 
-	var form models.Form
+	var form *models.Form
+	var member bool
 	{
 		ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
 		defer cancel()
-		if err := h.Store.Forms.FindOne(ctx, bson.M{"_id": formID}).Decode(&form); err != nil {
+		f, err := h.Store.GetForm(ctx, formID)
+		if err != nil {
 			return fiber.NewError(fiber.StatusNotFound, "form not found")
 		}
+		if member, err = canReadResults(ctx, h.Store, f, userID); err != nil {
+			return err
+		}
+		form = f
 	}
 
 	ctx, cancel := context.WithTimeout(c.Context(), 20*time.Second)
 	defer cancel()
 
-	out, err := computeAnalytics(ctx, h.Store, formID, &form)
+	out, err := computeAnalytics(ctx, h.Store, formID, form)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
+	if !member {
+		out = out.publicCopy()
+	}
 	return c.JSON(out)
 }
 
+type publicResultsReq struct {
+	Enabled bool `json:"enabled"`
+}
+
+// SetPublicResults turns the public results mode of a form on or off. Owner
+// only, since it discloses results beyond the people the form is shared with.
+func (h *AnalyticsHandler) SetPublicResults(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	form, err := loadFormFor(c, h.Store, c.Params("id"), userID, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	var in publicResultsReq
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if form.PublicResults != in.Enabled {
+		form.PublicResults = in.Enabled
+		if err := saveFormMeta(c, h.Store, form); err != nil {
+			return err
+		}
+	}
+	return c.JSON(form)
+}
+
 
 type Trends struct {
 	AvgRating   float64                `json:"avgRating,omitempty"`
 	MostCommon  map[string]interface{} `json:"mostCommon,omitempty"`
 	Skipped     map[string]int         `json:"skipped,omitempty"`
 	MostSkipped []fiber.Map            `json:"mostSkipped,omitempty"` 
+	// NPS and NPSTrend pool the answers of every nps field on the form;
+	// NPS is a pointer since 0 is a valid score.
+	NPS      *float64   `json:"nps,omitempty"`
+	NPSTrend []npsPoint `json:"npsTrend,omitempty"`
+}
+
+// publicAnalyticsKeys name the per-field entries that aggregate answers and
+// may be shown with public results. Anything else, such as free-text
+// "otherValues" or the min/max and earliest/latest extremes that repeat a
+// single answer, is withheld; new summary keys stay private until added here.
+var publicAnalyticsKeys = map[string]bool{
+	"type": true, "distribution": true, "other": true, "average": true,
+	"top2Box": true, "bottom2Box": true, "nonEmptyCount": true, "answered": true,
+	"count": true, "mean": true, "median": true, "stddev": true,
+	"percentiles": true, "histogram": true, "unit": true,
+	"byDay": true, "byWeek": true, "byMonth": true, "byHour": true,
+	"ranked": true, "averageRank": true, "firstChoice": true, "bordaScore": true,
+	"promoters": true, "passives": true, "detractors": true, "nps": true, "trend": true,
+	"totalSize": true, "byContentType": true,
 }
 
 type Analytics struct {
@@ -56,18 +115,11 @@ type Analytics struct {
 	Trends *Trends                `json:"trends,omitempty"`
 }
 
-func computeAnalytics(ctx context.Context, store *db.MongoStore, formID string, form *models.Form) (*Analytics, error) {
-	cursor, err := store.Responses.Aggregate(ctx, bson.A{
-		bson.M{"$match": bson.M{"formId": formID}},
-		bson.M{"$project": bson.M{"answers": 1}},
-	})
+func computeAnalytics(ctx context.Context, store db.Store, formID string, form *models.Form) (*Analytics, error) {
+	rows, err := store.ListResponses(ctx, formID)
 	if err != nil {
 		return nil, err
 	}
-	var rows []bson.M
-	if err := cursor.All(ctx, &rows); err != nil {
-		return nil, err
-	}
 
 	total := len(rows)
 	fields := map[string]interface{}{}
@@ -86,26 +138,30 @@ func computeAnalytics(ctx context.Context, store *db.MongoStore, formID string,
 		return id
 	}
 
-	for _, f := range form.Fields {
+	var npsFields []*models.FormField
+	for i, f := range form.Fields {
 		switch f.Type {
-		case models.FieldMultiple:
+		case models.FieldMultiple, models.FieldDropdown:
 			counts := map[string]int{}
 			for _, opt := range f.Options {
 				counts[opt] = 0
 			}
+			var others []string
 			seen := 0
 			for _, r := range rows {
-				ans := r["answers"].(bson.M)
+				ans := r.Answers
 				if v, ok := ans[f.ID]; ok {
 					if s, ok := v.(string); ok && s != "" {
 						if _, exist := counts[s]; exist {
 							counts[s]++
+						} else {
+							others = append(others, s)
 						}
 						seen++
 					}
 				}
 			}
-			fields[f.ID] = fiber.Map{"type": f.Type, "distribution": counts}
+			fields[f.ID] = withOther(&f, fiber.Map{"type": f.Type, "distribution": counts}, others)
 			skipped[f.ID] = total - seen
 
 			topOpt := ""
@@ -125,22 +181,25 @@ func computeAnalytics(ctx context.Context, store *db.MongoStore, formID string,
 			for _, opt := range f.Options {
 				counts[opt] = 0
 			}
+			var others []string
 			seen := 0
 			for _, r := range rows {
-				ans := r["answers"].(bson.M)
+				ans := r.Answers
 				if v, ok := ans[f.ID]; ok {
 					arr, ok := toStringSlice(v)
 					if ok && len(arr) > 0 {
 						for _, s := range arr {
 							if _, exist := counts[s]; exist {
 								counts[s]++
+							} else {
+								others = append(others, s)
 							}
 						}
 						seen++
 					}
 				}
 			}
-			fields[f.ID] = fiber.Map{"type": f.Type, "distribution": counts}
+			fields[f.ID] = withOther(&f, fiber.Map{"type": f.Type, "distribution": counts}, others)
 			skipped[f.ID] = total - seen
 
 			topCnt := -1
@@ -158,10 +217,7 @@ func computeAnalytics(ctx context.Context, store *db.MongoStore, formID string,
 			}
 
 		case models.FieldRating:
-			max := f.Max
-			if max <= 0 {
-				max = 5
-			}
+			max := ratingMax(&f)
 			dist := make(map[int]int, max)
 			sum := 0.0
 			n := 0
@@ -169,7 +225,7 @@ func computeAnalytics(ctx context.Context, store *db.MongoStore, formID string,
 				dist[i] = 0
 			}
 			for _, r := range rows {
-				ans := r["answers"].(bson.M)
+				ans := r.Answers
 				if v, ok := ans[f.ID]; ok {
 					if num, ok := toFloat64(v); ok {
 						iv := int(math.Round(num))
@@ -191,10 +247,10 @@ func computeAnalytics(ctx context.Context, store *db.MongoStore, formID string,
 			globalRatingSum += sum
 			globalRatingCount += n
 
-		case models.FieldText:
+		case models.FieldText, models.FieldEmail, models.FieldURL, models.FieldPhone:
 			nonEmpty := 0
 			for _, r := range rows {
-				ans := r["answers"].(bson.M)
+				ans := r.Answers
 				if v, ok := ans[f.ID]; ok {
 					if s, ok := v.(string); ok && s != "" {
 						nonEmpty++
@@ -203,11 +259,57 @@ func computeAnalytics(ctx context.Context, store *db.MongoStore, formID string,
 			}
 			fields[f.ID] = fiber.Map{"type": f.Type, "nonEmptyCount": nonEmpty}
 			skipped[f.ID] = total - nonEmpty
+
+		case models.FieldNumber:
+			summary, n := numberAnalytics(&f, rows)
+			fields[f.ID] = summary
+			skipped[f.ID] = total - n
+
+		case models.FieldSlider:
+			summary, n := sliderAnalytics(&f, rows)
+			fields[f.ID] = summary
+			skipped[f.ID] = total - n
+
+		case models.FieldDate, models.FieldTime, models.FieldDateTime:
+			summary, n := temporalAnalytics(&f, rows)
+			fields[f.ID] = summary
+			skipped[f.ID] = total - n
+
+		case models.FieldMatrix:
+			summary, n := matrixAnalytics(&f, rows)
+			fields[f.ID] = summary
+			skipped[f.ID] = total - n
+
+		case models.FieldRanking:
+			summary, n := rankingAnalytics(&f, rows)
+			fields[f.ID] = summary
+			skipped[f.ID] = total - n
+
+		case models.FieldNPS:
+			summary, n := npsAnalytics(&f, rows)
+			fields[f.ID] = summary
+			skipped[f.ID] = total - n
+			npsFields = append(npsFields, &form.Fields[i])
+
+		case models.FieldYesNo:
+			summary, n := yesNoAnalytics(&f, rows)
+			fields[f.ID] = summary
+			skipped[f.ID] = total - n
+
+		case models.FieldLikert:
+			summary, n := likertAnalytics(&f, rows)
+			fields[f.ID] = summary
+			skipped[f.ID] = total - n
+
+		case models.FieldFile:
+			summary, n := fileAnalytics(&f, rows)
+			fields[f.ID] = summary
+			skipped[f.ID] = total - n
 		}
 	}
 
 	mostSkipped := make([]fiber.Map, 0, len(form.Fields))
-	for _, f := range form.Fields {
+	for _, f := range answerFields(form) {
 		mostSkipped = append(mostSkipped, fiber.Map{
 			"id":      f.ID,
 			"label":   labelOf(f.ID),
@@ -233,6 +335,14 @@ func computeAnalytics(ctx context.Context, store *db.MongoStore, formID string,
 		Skipped:     skipped,
 		MostSkipped: mostSkipped,
 	}
+	if len(npsFields) > 0 {
+		overall, weeks := tallyNPS(npsFields, rows)
+		if overall.count() > 0 {
+			score := overall.score()
+			trends.NPS = &score
+			trends.NPSTrend = npsTrend(weeks)
+		}
+	}
 
 	return &Analytics{
 		FormID: formID,
@@ -241,3 +351,40 @@ func computeAnalytics(ctx context.Context, store *db.MongoStore, formID string,
 		Trends: trends,
 	}, nil
 }
+
+// withOther adds the "other" bucket of a choice field that allows free-text
+// answers. It is kept out of the distribution so it cannot collide with an
+// option that happens to be called "Other".
+func withOther(f *models.FormField, summary fiber.Map, others []string) fiber.Map {
+	if !f.AllowOther {
+		return summary
+	}
+	if others == nil {
+		others = []string{}
+	}
+	summary["other"] = len(others)
+	summary["otherValues"] = others
+	return summary
+}
+
+// publicCopy returns the analytics reduced to publicAnalyticsKeys, for
+// callers who only see results because the form has public results on.
+func (a *Analytics) publicCopy() *Analytics {
+	cp := *a
+	cp.Fields = make(map[string]interface{}, len(a.Fields))
+	for id, v := range a.Fields {
+		m, ok := v.(fiber.Map)
+		if !ok {
+			cp.Fields[id] = v
+			continue
+		}
+		redacted := make(fiber.Map, len(m))
+		for k, x := range m {
+			if publicAnalyticsKeys[k] {
+				redacted[k] = x
+			}
+		}
+		cp.Fields[id] = redacted
+	}
+	return &cp
+}
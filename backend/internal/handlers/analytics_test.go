@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func analyticsFields() []models.FormField {
+	return []models.FormField{
+		{ID: "color", Type: models.FieldMultiple, Label: "Color", Options: []string{"Red", "Blue"}, AllowOther: true},
+		{ID: "rate", Type: models.FieldRating, Label: "Rate"},
+		{ID: "score", Type: models.FieldNPS, Label: "Recommend?"},
+		{ID: "note", Type: models.FieldText, Label: "Note"},
+	}
+}
+
+// submitAll posts each answer set as an anonymous response.
+func (s *testServer) submitAll(formID string, answers ...fiber.Map) {
+	s.t.Helper()
+	for _, a := range answers {
+		if status, body := s.do("POST", "/api/forms/"+formID+"/response", "", fiber.Map{"answers": a}); status != fiber.StatusCreated {
+			s.t.Fatalf("submit %v: %d %s", a, status, body)
+		}
+	}
+}
+
+func TestAnalytics(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	f := s.createForm(owner, fiber.Map{"title": "Survey", "status": "published", "fields": analyticsFields()})
+	s.submitAll(f.ID,
+		fiber.Map{"color": "Red", "rate": 4, "score": 10},
+		fiber.Map{"color": "Red", "rate": 2, "score": 3},
+		fiber.Map{"color": "Green", "note": "hi"},
+	)
+
+	status, body := s.do("GET", "/api/forms/"+f.ID+"/analytics", owner, nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("analytics: %d %s", status, body)
+	}
+	a := decode[Analytics](t, body)
+	if a.Count != 3 {
+		t.Errorf("count = %d, want 3", a.Count)
+	}
+	color := a.Fields["color"].(map[string]interface{})
+	if dist := color["distribution"].(map[string]interface{}); dist["Red"] != 2.0 || dist["Blue"] != 0.0 || color["other"] != 1.0 {
+		t.Errorf("color = %v", color)
+	}
+	if vals := color["otherValues"].([]interface{}); len(vals) != 1 || vals[0] != "Green" {
+		t.Errorf("otherValues = %v", vals)
+	}
+	if a.Fields["rate"].(map[string]interface{})["average"] != 3.0 {
+		t.Errorf("rate = %v", a.Fields["rate"])
+	}
+	tr := a.Trends
+	if tr.AvgRating != 3 || tr.MostCommon["color"] != "Red" || tr.Skipped["note"] != 2 || tr.Skipped["rate"] != 1 {
+		t.Errorf("trends = %+v", tr)
+	}
+	if tr.NPS == nil || *tr.NPS != 0 || len(tr.NPSTrend) != 1 {
+		t.Errorf("nps = %v %v", tr.NPS, tr.NPSTrend)
+	}
+	if len(tr.MostSkipped) != 3 || tr.MostSkipped[0]["id"] != "note" {
+		t.Errorf("mostSkipped = %v", tr.MostSkipped)
+	}
+}
+
+func TestPublicResults(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	stranger := s.user("stranger@x.io")
+	f := s.createForm(owner, fiber.Map{"title": "Survey", "status": "published", "fields": analyticsFields()})
+	s.submitAll(f.ID, fiber.Map{"color": "Green"})
+	path := "/api/forms/" + f.ID + "/analytics"
+
+	if status, _ := s.do("GET", path, "", nil); status != fiber.StatusUnauthorized {
+		t.Errorf("anonymous before opt-in: %d, want 401", status)
+	}
+	if status, _ := s.do("GET", path, stranger, nil); status != fiber.StatusForbidden {
+		t.Errorf("stranger before opt-in: %d, want 403", status)
+	}
+	if status, _ := s.do("PUT", "/api/forms/"+f.ID+"/public-results", stranger, fiber.Map{"enabled": true}); status != fiber.StatusForbidden {
+		t.Errorf("stranger opts in: %d, want 403", status)
+	}
+	if status, body := s.do("PUT", "/api/forms/"+f.ID+"/public-results", owner, fiber.Map{"enabled": true}); status != fiber.StatusOK {
+		t.Fatalf("opt in: %d %s", status, body)
+	}
+
+	status, body := s.do("GET", path, "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("anonymous after opt-in: %d %s", status, body)
+	}
+	color := decode[Analytics](t, body).Fields["color"].(map[string]interface{})
+	if _, ok := color["otherValues"]; ok || color["other"] != 1.0 {
+		t.Errorf("public color = %v; want the count without verbatim answers", color)
+	}
+	_, body = s.do("GET", path, owner, nil)
+	if color := decode[Analytics](t, body).Fields["color"].(map[string]interface{}); color["otherValues"] == nil {
+		t.Error("owner lost otherValues")
+	}
+}
+
+func TestPublicResultsHideExtremes(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	f := s.createForm(owner, fiber.Map{"title": "Survey", "status": "published", "fields": []models.FormField{
+		{ID: "age", Type: models.FieldNumber, Label: "Age"},
+		{ID: "born", Type: models.FieldDate, Label: "Born"},
+	}})
+	s.submitAll(f.ID, fiber.Map{"age": 31, "born": "1993-04-02"}, fiber.Map{"age": 47, "born": "1977-11-20"})
+	if status, body := s.do("PUT", "/api/forms/"+f.ID+"/public-results", owner, fiber.Map{"enabled": true}); status != fiber.StatusOK {
+		t.Fatalf("opt in: %d %s", status, body)
+	}
+	path := "/api/forms/" + f.ID + "/analytics"
+
+	_, body := s.do("GET", path, "", nil)
+	public := decode[Analytics](t, body).Fields
+	age := public["age"].(map[string]interface{})
+	born := public["born"].(map[string]interface{})
+	for _, k := range []string{"min", "max"} {
+		if _, ok := age[k]; ok {
+			t.Errorf("public age has %s: %v", k, age)
+		}
+	}
+	for _, k := range []string{"earliest", "latest"} {
+		if _, ok := born[k]; ok {
+			t.Errorf("public born has %s: %v", k, born)
+		}
+	}
+	if age["mean"] != 39.0 || born["byMonth"] == nil {
+		t.Errorf("public results lost aggregates: %v %v", age, born)
+	}
+
+	_, body = s.do("GET", path, owner, nil)
+	private := decode[Analytics](t, body).Fields
+	if age := private["age"].(map[string]interface{}); age["min"] != 31.0 || age["max"] != 47.0 {
+		t.Errorf("owner age = %v", age)
+	}
+	if born := private["born"].(map[string]interface{}); born["earliest"] != "1977-11-20" {
+		t.Errorf("owner born = %v", born)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
-	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/google/uuid"
 
@@ -16,11 +15,11 @@ import (
 )
 
 type AuthHandler struct {
-	Store     *db.MongoStore
+	Store     db.Store
 	JWTSecret []byte
 }
 
-func NewAuthHandler(s *db.MongoStore, secret []byte) *AuthHandler {
+func NewAuthHandler(s db.Store, secret []byte) *AuthHandler {
 	return &AuthHandler{Store: s, JWTSecret: secret}
 }
 
@@ -56,7 +55,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
 	defer cancel()
 
-	if _, err := h.Store.Users.InsertOne(ctx, u); err != nil {
+	if err := h.Store.CreateUser(ctx, &u); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "email already registered")
 	}
 
@@ -74,8 +73,8 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
 	defer cancel()
 
-	var u models.User
-	if err := h.Store.Users.FindOne(ctx, bson.M{"email": in.Email}).Decode(&u); err != nil {
+	u, err := h.Store.GetUserByEmail(ctx, in.Email)
+	if err != nil {
 		return fiber.NewError(fiber.StatusUnauthorized, "invalid credentials")
 	}
 	if err := bcrypt.CompareHashAndPassword(u.PasswordHash, []byte(in.Password)); err != nil {
@@ -84,7 +83,7 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 
 	tok, _ := h.makeToken(u.ID)
 	c.Cookie(&fiber.Cookie{Name: "token", Value: tok, HTTPOnly: true, SameSite: "Lax"})
-	return c.JSON(authResp{Token: tok, User: u})
+	return c.JSON(authResp{Token: tok, User: *u})
 }
 
 func (h *AuthHandler) Me(c *fiber.Ctx) error {
@@ -95,8 +94,8 @@ func (h *AuthHandler) Me(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
 	defer cancel()
 
-	var u models.User
-	if err := h.Store.Users.FindOne(ctx, bson.M{"_id": uid}).Decode(&u); err != nil {
+	u, err := h.Store.GetUser(ctx, uid)
+	if err != nil {
 		return fiber.NewError(fiber.StatusUnauthorized, "not found")
 	}
 	u.PasswordHash = nil
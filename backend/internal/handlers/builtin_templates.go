@@ -0,0 +1,40 @@
+package handlers
+
+import "github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+
+// builtinTemplates ship with the server. They use the same schema as user
+// forms and are checked with validateField by NewTemplateHandler.
+var builtinTemplates = []models.Form{
+	{
+		ID:    "system-nps",
+		Title: "Net Promoter Score",
+		Fields: []models.FormField{
+			{ID: "score", Type: models.FieldNPS, Label: "How likely are you to recommend us to a friend or colleague?", Required: true},
+			{ID: "reason", Type: models.FieldText, Label: "What is the main reason for your score?"},
+			{ID: "improve", Type: models.FieldText, Label: "What could we do better?", ShowIf: &models.ShowIf{FieldID: "score", Operator: models.OpLte, Value: 6}},
+		},
+	},
+	{
+		ID:    "system-event-feedback",
+		Title: "Event Feedback",
+		Fields: []models.FormField{
+			{ID: "overall", Type: models.FieldRating, Label: "Overall, how would you rate the event?", Required: true, Max: floatPtr(5)},
+			{ID: "highlights", Type: models.FieldCheckbox, Label: "What did you enjoy most?", Options: []string{"Talks", "Workshops", "Networking", "Venue", "Food"}},
+			{ID: "attend_again", Type: models.FieldMultiple, Label: "Would you attend again?", Required: true, Options: []string{"Yes", "Maybe", "No"}},
+			{ID: "why_not", Type: models.FieldText, Label: "What would change your mind?", ShowIf: &models.ShowIf{FieldID: "attend_again", Operator: models.OpEq, Value: "No"}},
+			{ID: "comments", Type: models.FieldText, Label: "Any other comments?"},
+		},
+	},
+	{
+		ID:    "system-course-evaluation",
+		Title: "Course Evaluation",
+		Fields: []models.FormField{
+			{ID: "content", Type: models.FieldRating, Label: "The course content was relevant and well organized", Required: true, Max: floatPtr(5)},
+			{ID: "instructor", Type: models.FieldRating, Label: "The instructor explained concepts clearly", Required: true, Max: floatPtr(5)},
+			{ID: "workload", Type: models.FieldMultiple, Label: "The workload was", Required: true, Options: []string{"Too light", "About right", "Too heavy"}},
+			{ID: "materials", Type: models.FieldCheckbox, Label: "Which materials did you find useful?", Options: []string{"Lectures", "Slides", "Readings", "Assignments", "Office hours"}},
+			{ID: "best", Type: models.FieldText, Label: "What was the best part of the course?"},
+			{ID: "change", Type: models.FieldText, Label: "What one thing would you change?"},
+		},
+	},
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func validateChoiceField(f *models.FormField) error {
+	if len(f.Options) == 0 {
+		return fmt.Errorf("%s requires non-empty options", f.Type)
+	}
+	if f.Type != models.FieldCheckbox {
+		f.MinSelected, f.MaxSelected = 0, 0
+		return nil
+	}
+	if f.MinSelected < 0 || f.MaxSelected < 0 {
+		return fmt.Errorf("minSelected and maxSelected must not be negative")
+	}
+	if f.MaxSelected > 0 && f.MinSelected > f.MaxSelected {
+		return fmt.Errorf("minSelected must not be greater than maxSelected")
+	}
+	// an "other" answer counts as one extra choice
+	choices := len(f.Options)
+	if f.AllowOther {
+		choices++
+	}
+	if f.MinSelected > choices {
+		return fmt.Errorf("minSelected must not exceed the number of choices (%d)", choices)
+	}
+	return nil
+}
+
+// checkChoice validates one selected value. Values outside the options are
+// "other" answers, accepted trimmed when the field allows them.
+func checkChoice(f *models.FormField, s string) (string, error) {
+	if contains(f.Options, s) {
+		return s, nil
+	}
+	if f.AllowOther {
+		if s = strings.TrimSpace(s); s != "" {
+			return s, nil
+		}
+	}
+	if f.Type == models.FieldCheckbox {
+		return "", fmt.Errorf("field '%s' contains invalid option '%s'", f.ID, s)
+	}
+	return "", fmt.Errorf("field '%s' must be one of %v", f.ID, f.Options)
+}
+
+// checkCheckboxAnswer validates a non-empty checkbox answer and returns the
+// normalized selection. At most one value may be an "other" answer, so the
+// selection limits keep counting options.
+func checkCheckboxAnswer(f *models.FormField, arr []string) ([]string, error) {
+	out := make([]string, 0, len(arr))
+	others := 0
+	for _, item := range arr {
+		s, err := checkChoice(f, item)
+		if err != nil {
+			return nil, err
+		}
+		if contains(out, s) {
+			return nil, fmt.Errorf("field '%s' selects '%s' more than once", f.ID, s)
+		}
+		if !contains(f.Options, s) {
+			if others++; others > 1 {
+				return nil, fmt.Errorf("field '%s' allows only one other answer", f.ID)
+			}
+		}
+		out = append(out, s)
+	}
+	if f.MinSelected > 0 && len(out) < f.MinSelected {
+		return nil, fmt.Errorf("field '%s' requires at least %d selections", f.ID, f.MinSelected)
+	}
+	if f.MaxSelected > 0 && len(out) > f.MaxSelected {
+		return nil, fmt.Errorf("field '%s' allows at most %d selections", f.ID, f.MaxSelected)
+	}
+	return out, nil
+}
+
+// yesNoAnalytics counts the answers of a yes/no field. It returns the summary
+// and the number of responses that answered.
+func yesNoAnalytics(f *models.FormField, rows []models.Response) (fiber.Map, int) {
+	yes, no := 0, 0
+	for _, r := range rows {
+		if b, ok := r.Answers[f.ID].(bool); ok {
+			if b {
+				yes++
+			} else {
+				no++
+			}
+		}
+	}
+	return fiber.Map{"type": f.Type, "distribution": map[string]int{"yes": yes, "no": no}}, yes + no
+}
+
+func yesNoLabel(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func TestValidateChoiceField(t *testing.T) {
+	opts := []string{"A", "B", "C"}
+	tests := []struct {
+		name string
+		f    models.FormField
+		ok   bool
+	}{
+		{"no options", models.FormField{Type: models.FieldMultiple}, false},
+		{"multiple", models.FormField{Type: models.FieldMultiple, Options: opts}, true},
+		{"min > max", models.FormField{Type: models.FieldCheckbox, Options: opts, MinSelected: 2, MaxSelected: 1}, false},
+		{"negative", models.FormField{Type: models.FieldCheckbox, Options: opts, MaxSelected: -1}, false},
+		{"min = options", models.FormField{Type: models.FieldCheckbox, Options: opts, MinSelected: 3}, true},
+		{"min > options", models.FormField{Type: models.FieldCheckbox, Options: opts, MinSelected: 4}, false},
+		{"min = options + other", models.FormField{Type: models.FieldCheckbox, Options: opts, MinSelected: 4, AllowOther: true}, true},
+		{"min > options + other", models.FormField{Type: models.FieldCheckbox, Options: opts, MinSelected: 5, AllowOther: true}, false},
+	}
+	for _, tt := range tests {
+		err := validateChoiceField(&tt.f)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestValidateChoiceFieldClearsLimitsOnSingleChoice(t *testing.T) {
+	f := models.FormField{Type: models.FieldDropdown, Options: []string{"A"}, MinSelected: 3, MaxSelected: 4}
+	if err := validateChoiceField(&f); err != nil {
+		t.Fatal(err)
+	}
+	if f.MinSelected != 0 || f.MaxSelected != 0 {
+		t.Errorf("limits = %d..%d, want cleared", f.MinSelected, f.MaxSelected)
+	}
+}
+
+func TestCheckChoice(t *testing.T) {
+	f := &models.FormField{ID: "c", Type: models.FieldMultiple, Options: []string{"A", "B"}}
+	if got, err := checkChoice(f, "A"); err != nil || got != "A" {
+		t.Errorf("checkChoice(A) = %q, %v", got, err)
+	}
+	if _, err := checkChoice(f, "Z"); err == nil {
+		t.Error("checkChoice accepted a value outside the options")
+	}
+
+	f.AllowOther = true
+	if got, err := checkChoice(f, "  Z  "); err != nil || got != "Z" {
+		t.Errorf("checkChoice(other) = %q, %v; want trimmed", got, err)
+	}
+	if _, err := checkChoice(f, "   "); err == nil {
+		t.Error("checkChoice accepted a blank other answer")
+	}
+}
+
+func TestCheckCheckboxAnswer(t *testing.T) {
+	f := &models.FormField{ID: "c", Type: models.FieldCheckbox, Options: []string{"A", "B", "C"}, MinSelected: 1, MaxSelected: 2}
+	tests := []struct {
+		name  string
+		other bool
+		in    []string
+		want  []string
+	}{
+		{"one", false, []string{"A"}, []string{"A"}},
+		{"two", false, []string{"A", "C"}, []string{"A", "C"}},
+		{"too many", false, []string{"A", "B", "C"}, nil},
+		{"duplicate", false, []string{"A", "A"}, nil},
+		{"unknown", false, []string{"Z"}, nil},
+		{"other", true, []string{"A", " Z "}, []string{"A", "Z"}},
+		{"only other", true, []string{"Z"}, []string{"Z"}},
+		{"two others", true, []string{"Y", "Z"}, nil},
+		{"other duplicate after trim", true, []string{"Z", " Z"}, nil},
+	}
+	for _, tt := range tests {
+		f.AllowOther = tt.other
+		got, err := checkCheckboxAnswer(f, tt.in)
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("%s: accepted %v", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, %v; want %v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestYesNoAnalytics(t *testing.T) {
+	f := &models.FormField{ID: "y", Type: models.FieldYesNo}
+	rows := []models.Response{
+		{Answers: map[string]interface{}{"y": true}},
+		{Answers: map[string]interface{}{"y": true}},
+		{Answers: map[string]interface{}{"y": false}},
+		{Answers: map[string]interface{}{"y": "yes"}},
+		{Answers: map[string]interface{}{}},
+	}
+	out, n := yesNoAnalytics(f, rows)
+	if n != 3 {
+		t.Errorf("answered = %d, want 3", n)
+	}
+	if got := out["distribution"].(map[string]int); got["yes"] != 2 || got["no"] != 1 {
+		t.Errorf("distribution = %v", got)
+	}
+}
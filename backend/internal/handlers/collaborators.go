@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/db"
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+type CollaboratorHandler struct {
+	Store db.Store
+}
+
+func NewCollaboratorHandler(s db.Store) *CollaboratorHandler { return &CollaboratorHandler{Store: s} }
+
+type collaboratorView struct {
+	UserID string      `json:"userId"`
+	Email  string      `json:"email,omitempty"`
+	Name   string      `json:"name,omitempty"`
+	Role   models.Role `json:"role"`
+}
+
+type shareReq struct {
+	Email string      `json:"email"`
+	Role  models.Role `json:"role"`
+}
+
+// transferReq names exactly one new owner: a user by email or a workspace.
+type transferReq struct {
+	Email       string `json:"email"`
+	WorkspaceID string `json:"workspaceId"`
+}
+
+// ListCollaborators returns the owner followed by everyone the form is shared
+// with; any member may see who else has access. Workspace forms have no owner
+// entry, their members are listed under the workspace.
+func (h *CollaboratorHandler) ListCollaborators(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	form, err := loadFormFor(c, h.Store, c.Params("id"), userID, models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	out := []collaboratorView{}
+	if form.OwnerID != "" {
+		out = append(out, describe(ctx, h.Store, form.OwnerID, models.RoleOwner))
+	}
+	for _, m := range form.Collaborators {
+		out = append(out, describe(ctx, h.Store, m.UserID, m.Role))
+	}
+	return c.JSON(out)
+}
+
+// ShareForm adds a registered user as editor or viewer, or changes the role
+// of an existing collaborator. Owner only.
+func (h *CollaboratorHandler) ShareForm(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	form, err := loadFormFor(c, h.Store, c.Params("id"), userID, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	var in shareReq
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if in.Role != models.RoleEditor && in.Role != models.RoleViewer {
+		return fiber.NewError(fiber.StatusBadRequest, "role must be editor or viewer")
+	}
+	u, err := findUser(c, h.Store, in.Email)
+	if err != nil {
+		return err
+	}
+	if u.ID == form.OwnerID {
+		return fiber.NewError(fiber.StatusBadRequest, "user already owns this form")
+	}
+
+	form.Collaborators = withoutCollaborator(form.Collaborators, u.ID)
+	form.Collaborators = append(form.Collaborators, models.Collaborator{UserID: u.ID, Role: in.Role})
+	if err := saveFormMeta(c, h.Store, form); err != nil {
+		return err
+	}
+	return c.JSON(form.Collaborators)
+}
+
+// RemoveCollaborator revokes access. Owners may remove anyone; collaborators
+// may only remove themselves.
+func (h *CollaboratorHandler) RemoveCollaborator(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	target := c.Params("userId")
+	min := models.RoleOwner
+	if target == userID {
+		min = models.RoleViewer
+	}
+	form, err := loadFormFor(c, h.Store, c.Params("id"), userID, min)
+	if err != nil {
+		return err
+	}
+	if target == form.OwnerID {
+		return fiber.NewError(fiber.StatusBadRequest, "the owner cannot be removed; transfer ownership instead")
+	}
+
+	n := len(form.Collaborators)
+	form.Collaborators = withoutCollaborator(form.Collaborators, target)
+	if len(form.Collaborators) == n {
+		return fiber.NewError(fiber.StatusNotFound, "collaborator not found")
+	}
+	if err := saveFormMeta(c, h.Store, form); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// TransferOwnership hands the form to another user or to a workspace. A user
+// who gives a form to another user stays on as an editor so they do not lose
+// access outright; moving a form out of a workspace drops the access of its
+// members. Moving a form into a workspace requires editing rights there.
+func (h *CollaboratorHandler) TransferOwnership(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	form, err := loadFormFor(c, h.Store, c.Params("id"), userID, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	var in transferReq
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	in.Email = strings.TrimSpace(in.Email)
+	if (in.Email == "") == (in.WorkspaceID == "") {
+		return fiber.NewError(fiber.StatusBadRequest, "give either email or workspaceId")
+	}
+
+	prev := form.OwnerID
+	if in.WorkspaceID != "" {
+		if in.WorkspaceID == form.WorkspaceID {
+			return c.JSON(form)
+		}
+		if _, err := loadWorkspaceFor(c, h.Store, in.WorkspaceID, userID, models.RoleEditor); err != nil {
+			return err
+		}
+		form.WorkspaceID = in.WorkspaceID
+		form.OwnerID = ""
+	} else {
+		u, err := findUser(c, h.Store, in.Email)
+		if err != nil {
+			return err
+		}
+		if u.ID == form.OwnerID {
+			return c.JSON(form)
+		}
+		form.WorkspaceID = ""
+		form.OwnerID = u.ID
+		form.Collaborators = withoutCollaborator(form.Collaborators, u.ID)
+		if prev != "" {
+			form.Collaborators = append(form.Collaborators, models.Collaborator{UserID: prev, Role: models.RoleEditor})
+		}
+	}
+	if err := saveFormMeta(c, h.Store, form); err != nil {
+		return err
+	}
+	return c.JSON(form)
+}
+
+func findUser(c *fiber.Ctx, store db.Store, email string) (*models.User, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "email is required")
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
+	defer cancel()
+
+	u, err := store.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "no user with that email")
+	}
+	return u, nil
+}
+
+func describe(ctx context.Context, store db.Store, userID string, role models.Role) collaboratorView {
+	v := collaboratorView{UserID: userID, Role: role}
+	if u, err := store.GetUser(ctx, userID); err == nil {
+		v.Email = u.Email
+		v.Name = u.Name
+	}
+	return v
+}
+
+func withoutCollaborator(list []models.Collaborator, userID string) []models.Collaborator {
+	out := list[:0:0]
+	for _, m := range list {
+		if m.UserID != userID {
+			out = append(out, m)
+		}
+	}
+	return out
+}
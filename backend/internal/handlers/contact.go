@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+// phoneSeparators are stripped from phone answers before normalizing, as is
+// the "(0)" trunk hint of numbers written like +44 (0)20 7946 0958.
+var phoneSeparators = strings.NewReplacer("(0)", "", " ", "", "-", "", ".", "", "(", "", ")", "", "/", "")
+
+func validateContactField(f *models.FormField) error {
+	if f.Type != models.FieldPhone {
+		return nil
+	}
+	f.CountryCode = strings.TrimPrefix(strings.TrimSpace(f.CountryCode), "+")
+	if f.CountryCode != "" && (len(f.CountryCode) > 3 || !allDigits(f.CountryCode)) {
+		return fmt.Errorf("countryCode must be 1 to 3 digits")
+	}
+	return nil
+}
+
+// checkContactAnswer validates an email, url or phone answer and returns the
+// normalized value that is stored and exported.
+func checkContactAnswer(f *models.FormField, v interface{}) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("field '%s' must be string", f.ID)
+	}
+	var (
+		norm string
+		err  error
+	)
+	switch f.Type {
+	case models.FieldEmail:
+		norm, err = normalizeEmail(s)
+	case models.FieldURL:
+		norm, err = normalizeURL(s)
+	case models.FieldPhone:
+		norm, err = normalizePhone(s, f.CountryCode)
+	}
+	if err != nil {
+		return "", fmt.Errorf("field '%s' %v", f.ID, err)
+	}
+	return norm, nil
+}
+
+// normalizeEmail accepts a bare address and lowercases its domain; the local
+// part is left alone since it may be case sensitive.
+func normalizeEmail(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Name != "" || addr.Address != s {
+		return "", fmt.Errorf("must be an email address")
+	}
+	at := strings.LastIndex(s, "@")
+	domain := strings.ToLower(s[at+1:])
+	if !strings.Contains(domain, ".") {
+		return "", fmt.Errorf("must be an email address")
+	}
+	return s[:at+1] + domain, nil
+}
+
+// normalizeURL accepts http(s) URLs, assuming https when the scheme is left
+// out, and lowercases scheme and host.
+func normalizeURL(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" || strings.ContainsAny(u.Host, " ") {
+		return "", fmt.Errorf("must be a URL")
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("must be an http or https URL")
+	}
+	u.Host = strings.ToLower(u.Host)
+	if !strings.Contains(u.Hostname(), ".") && u.Hostname() != "localhost" {
+		return "", fmt.Errorf("must be a URL")
+	}
+	return u.String(), nil
+}
+
+// normalizePhone returns s in E.164 (+ and up to 15 digits). International
+// numbers may start with + or 00; national numbers drop their trunk 0 and
+// get countryCode prepended, so they are rejected when it is not set.
+func normalizePhone(s, countryCode string) (string, error) {
+	digits := phoneSeparators.Replace(strings.TrimSpace(s))
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	case countryCode != "":
+		digits = countryCode + strings.TrimPrefix(digits, "0")
+	default:
+		return "", fmt.Errorf("must be an international phone number starting with +")
+	}
+	if !allDigits(digits) || len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", fmt.Errorf("must be a valid phone number")
+	}
+	return "+" + digits, nil
+}
+
+func allDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func TestCheckContactAnswer(t *testing.T) {
+	tests := []struct {
+		typ     models.FieldType
+		country string
+		in      interface{}
+		want    string
+	}{
+		{models.FieldEmail, "", "Ann@Example.COM", "Ann@example.com"},
+		{models.FieldEmail, "", " ann@example.com ", "ann@example.com"},
+		{models.FieldEmail, "", "Ann <ann@example.com>", ""},
+		{models.FieldEmail, "", "ann@localhost", ""},
+		{models.FieldEmail, "", "ann", ""},
+		{models.FieldEmail, "", 42.0, ""},
+		{models.FieldURL, "", "Example.com/Path", "https://example.com/Path"},
+		{models.FieldURL, "", "HTTP://example.com", "http://example.com"},
+		{models.FieldURL, "", "http://localhost:8080", "http://localhost:8080"},
+		{models.FieldURL, "", "ftp://example.com", ""},
+		{models.FieldURL, "", "not a url", ""},
+		{models.FieldPhone, "", "+44 (0)20 7946 0958", "+442079460958"},
+		{models.FieldPhone, "", "0044 20 7946 0958", "+442079460958"},
+		{models.FieldPhone, "", "020 7946 0958", ""},
+		{models.FieldPhone, "44", "020 7946 0958", "+442079460958"},
+		{models.FieldPhone, "", "+1 555", ""},
+		{models.FieldPhone, "", "+1 555 abc 0100", ""},
+	}
+	for _, tt := range tests {
+		f := &models.FormField{ID: "c", Type: tt.typ, CountryCode: tt.country}
+		got, err := checkContactAnswer(f, tt.in)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s %v: accepted as %q", tt.typ, tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s %v = %q, %v; want %q", tt.typ, tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestValidateContactField(t *testing.T) {
+	f := &models.FormField{Type: models.FieldPhone, CountryCode: " +49 "}
+	if err := validateContactField(f); err != nil || f.CountryCode != "49" {
+		t.Errorf("countryCode = %q, %v; want 49", f.CountryCode, err)
+	}
+	for _, cc := range []string{"1234", "4a"} {
+		f := &models.FormField{Type: models.FieldPhone, CountryCode: cc}
+		if err := validateContactField(f); err == nil {
+			t.Errorf("countryCode %q accepted", cc)
+		}
+	}
+}
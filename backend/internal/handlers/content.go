@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func isDisplayOnly(t models.FieldType) bool {
+	switch t {
+	case models.FieldHeading, models.FieldParagraph, models.FieldImage, models.FieldDivider, models.FieldPageBreak:
+		return true
+	}
+	return false
+}
+
+// validateContentBlock checks a display-only block. Only headings need a
+// label; the label of a page break titles the page that follows it.
+func validateContentBlock(f *models.FormField) error {
+	if f.Required {
+		return fmt.Errorf("%s blocks cannot be required", f.Type)
+	}
+	switch f.Type {
+	case models.FieldHeading:
+		if f.Label == "" {
+			return fmt.Errorf("heading requires a label")
+		}
+	case models.FieldParagraph:
+		if f.Content = strings.TrimSpace(f.Content); f.Content == "" {
+			return fmt.Errorf("paragraph requires content")
+		}
+	case models.FieldImage:
+		u, err := normalizeURL(f.ImageURL)
+		if err != nil {
+			return fmt.Errorf("imageUrl %v", err)
+		}
+		f.ImageURL = u
+	}
+	return nil
+}
+
+// answerFields are the fields of a form that take answers, in form order;
+// exports and analytics work on these only.
+func answerFields(form *models.Form) []models.FormField {
+	out := make([]models.FormField, 0, len(form.Fields))
+	for _, f := range form.Fields {
+		if !isDisplayOnly(f.Type) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
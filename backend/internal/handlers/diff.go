@@ -0,0 +1,439 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type FieldRef struct {
+	ID       string `json:"id"`
+	Label    string `json:"label"`
+	Position int    `json:"position"`
+}
+
+type FieldMove struct {
+	ID   string `json:"id"`
+	From int    `json:"from"`
+	To   int    `json:"to"`
+}
+
+type FieldChange struct {
+	ID             string   `json:"id"`
+	Label          *Change  `json:"label,omitempty"`
+	Type           *Change  `json:"type,omitempty"`
+	Required       *Change  `json:"required,omitempty"`
+	OptionsAdded   []string `json:"optionsAdded,omitempty"`
+	OptionsRemoved []string `json:"optionsRemoved,omitempty"`
+	// OptionsReordered holds the order of the options kept on both sides
+	// before and after, when it changed.
+	OptionsReordered *Change           `json:"optionsReordered,omitempty"`
+	ShowIf           *Change           `json:"showIf,omitempty"`
+	Settings         map[string]Change `json:"settings,omitempty"`
+	// Breaking is set when answers collected before the change may no longer
+	// be valid or comparable; Reasons explains why.
+	Breaking bool     `json:"breaking"`
+	Reasons  []string `json:"reasons,omitempty"`
+}
+
+type FormDiff struct {
+	FormID    string        `json:"formId"`
+	From      int           `json:"from"`
+	To        int           `json:"to"`
+	Title     *Change       `json:"title,omitempty"`
+	Status    *Change       `json:"status,omitempty"`
+	Added     []FieldRef    `json:"added"`
+	Removed   []FieldRef    `json:"removed"`
+	Reordered bool          `json:"reordered"`
+	Moved     []FieldMove   `json:"moved"`
+	Changed   []FieldChange `json:"changed"`
+	Breaking  bool          `json:"breaking"`
+	Warnings  []string      `json:"warnings"`
+}
+
+// DiffRevisions compares ?from= with ?to= (default: the current revision).
+func (h *RevisionHandler) DiffRevisions(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	form, err := loadFormFor(c, h.Store, c.Params("id"), userID, models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	from := c.QueryInt("from", 0)
+	to := c.QueryInt("to", form.Revision)
+	if from <= 0 || to <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "from and to must be positive revisions")
+	}
+
+	a, err := fetchRevision(c, h.Store, form.ID, from)
+	if err != nil {
+		return err
+	}
+	b, err := fetchRevision(c, h.Store, form.ID, to)
+	if err != nil {
+		return err
+	}
+	return c.JSON(diffRevisions(a, b))
+}
+
+func diffRevisions(a, b *models.FormRevision) *FormDiff {
+	d := &FormDiff{
+		FormID:   b.FormID,
+		From:     a.Revision,
+		To:       b.Revision,
+		Added:    []FieldRef{},
+		Removed:  []FieldRef{},
+		Moved:    []FieldMove{},
+		Changed:  []FieldChange{},
+		Warnings: []string{},
+	}
+	if a.Title != b.Title {
+		d.Title = &Change{From: a.Title, To: b.Title}
+	}
+	if a.Status != b.Status {
+		d.Status = &Change{From: a.Status, To: b.Status}
+	}
+
+	oldIdx := make(map[string]int, len(a.Fields))
+	for i, f := range a.Fields {
+		oldIdx[f.ID] = i
+	}
+	newIdx := make(map[string]int, len(b.Fields))
+	for i, f := range b.Fields {
+		newIdx[f.ID] = i
+	}
+
+	for i, f := range a.Fields {
+		if _, ok := newIdx[f.ID]; !ok {
+			d.Removed = append(d.Removed, FieldRef{ID: f.ID, Label: f.Label, Position: i})
+			d.Breaking = true
+			d.Warnings = append(d.Warnings, fmt.Sprintf("field '%s' was removed; its collected answers are no longer shown", f.ID))
+		}
+	}
+	for i, f := range b.Fields {
+		if _, ok := oldIdx[f.ID]; !ok {
+			d.Added = append(d.Added, FieldRef{ID: f.ID, Label: f.Label, Position: i})
+		}
+	}
+
+	// Only fields present on both sides can move; compare their relative order
+	// so that an insertion or removal alone is not reported as a reorder.
+	var oldOrder, newOrder []string
+	for _, f := range a.Fields {
+		if _, ok := newIdx[f.ID]; ok {
+			oldOrder = append(oldOrder, f.ID)
+		}
+	}
+	for _, f := range b.Fields {
+		if _, ok := oldIdx[f.ID]; ok {
+			newOrder = append(newOrder, f.ID)
+		}
+	}
+	for i := range newOrder {
+		if oldOrder[i] != newOrder[i] {
+			d.Reordered = true
+			break
+		}
+	}
+	if d.Reordered {
+		seq := make([]int, len(newOrder))
+		for i, id := range newOrder {
+			seq[i] = oldIdx[id]
+		}
+		// Fields outside the longest run that kept its relative order are the
+		// ones the editor actually dragged.
+		stay := longestIncreasing(seq)
+		for i, id := range newOrder {
+			if !stay[i] {
+				d.Moved = append(d.Moved, FieldMove{ID: id, From: oldIdx[id], To: newIdx[id]})
+			}
+		}
+	}
+
+	for _, nf := range b.Fields {
+		i, ok := oldIdx[nf.ID]
+		if !ok {
+			continue
+		}
+		if fc, changed := diffField(&a.Fields[i], &nf); changed {
+			if fc.Breaking {
+				d.Breaking = true
+			}
+			for _, r := range fc.Reasons {
+				d.Warnings = append(d.Warnings, fmt.Sprintf("field '%s': %s", fc.ID, r))
+			}
+			d.Changed = append(d.Changed, fc)
+		}
+	}
+	return d
+}
+
+// longestIncreasing marks the members of one longest strictly increasing
+// subsequence of seq.
+func longestIncreasing(seq []int) []bool {
+	n := len(seq)
+	length := make([]int, n)
+	prev := make([]int, n)
+	best := -1
+	for i := 0; i < n; i++ {
+		length[i], prev[i] = 1, -1
+		for j := 0; j < i; j++ {
+			if seq[j] < seq[i] && length[j]+1 > length[i] {
+				length[i], prev[i] = length[j]+1, j
+			}
+		}
+		if best < 0 || length[i] > length[best] {
+			best = i
+		}
+	}
+	keep := make([]bool, n)
+	for i := best; i >= 0; i = prev[i] {
+		keep[i] = true
+	}
+	return keep
+}
+
+func diffField(a, b *models.FormField) (FieldChange, bool) {
+	fc := FieldChange{ID: b.ID}
+	changed := false
+
+	if a.Label != b.Label {
+		fc.Label = &Change{From: a.Label, To: b.Label}
+		changed = true
+	}
+	if a.Type != b.Type {
+		fc.Type = &Change{From: a.Type, To: b.Type}
+		fc.Breaking = true
+		fc.Reasons = append(fc.Reasons, fmt.Sprintf("type changed from %s to %s", a.Type, b.Type))
+		changed = true
+	}
+	if a.Required != b.Required {
+		fc.Required = &Change{From: a.Required, To: b.Required}
+		if b.Required {
+			fc.Reasons = append(fc.Reasons, "now required; earlier responses may have skipped it")
+		}
+		changed = true
+	}
+	for _, o := range b.Options {
+		if !contains(a.Options, o) {
+			fc.OptionsAdded = append(fc.OptionsAdded, o)
+			changed = true
+		}
+	}
+	for _, o := range a.Options {
+		if !contains(b.Options, o) {
+			fc.OptionsRemoved = append(fc.OptionsRemoved, o)
+			changed = true
+		}
+	}
+	if len(fc.OptionsRemoved) > 0 {
+		fc.Breaking = true
+		fc.Reasons = append(fc.Reasons, fmt.Sprintf("options removed: %v", fc.OptionsRemoved))
+	}
+	if from, to := keptOrder(a.Options, b.Options), keptOrder(b.Options, a.Options); !reflect.DeepEqual(from, to) {
+		fc.OptionsReordered = &Change{From: from, To: to}
+		changed = true
+		// a likert answer is a position on the scale, and respondents rank
+		// starting from the presented order, so earlier answers no longer
+		// compare with new ones
+		if b.Type == models.FieldLikert || b.Type == models.FieldRanking {
+			fc.Breaking = true
+			fc.Reasons = append(fc.Reasons, "options reordered; earlier answers were given against the old order")
+		}
+	}
+	if a.Type == models.FieldMatrix && b.Type == models.FieldMatrix {
+		// answers are keyed by row and name columns, so dropping either
+		// orphans them like a removed option
+		if rows := missing(a.Rows, b.Rows); len(rows) > 0 {
+			fc.Breaking = true
+			fc.Reasons = append(fc.Reasons, fmt.Sprintf("rows removed: %v", rows))
+		}
+		if cols := missing(a.Columns, b.Columns); len(cols) > 0 {
+			fc.Breaking = true
+			fc.Reasons = append(fc.Reasons, fmt.Sprintf("columns removed: %v", cols))
+		}
+		if a.MultiSelect != b.MultiSelect {
+			fc.Breaking = true
+			fc.Reasons = append(fc.Reasons, "switched between one and several columns per row")
+		}
+	}
+	if !reflect.DeepEqual(a.ShowIf, b.ShowIf) {
+		fc.ShowIf = &Change{From: a.ShowIf, To: b.ShowIf}
+		fc.Reasons = append(fc.Reasons, "display condition changed; skip counts are not comparable")
+		changed = true
+	}
+
+	if settings := diffSettings(a, b); len(settings) > 0 {
+		fc.Settings = settings
+		changed = true
+		if a.Type == b.Type {
+			if reasons := tightenedConstraints(a, b); len(reasons) > 0 {
+				fc.Breaking = true
+				fc.Reasons = append(fc.Reasons, reasons...)
+			}
+		}
+	}
+	return fc, changed
+}
+
+// tightenedConstraints lists the answer constraints of a field that b makes
+// stricter than a, so that answers valid under a may now be rejected.
+func tightenedConstraints(a, b *models.FormField) []string {
+	var out []string
+	switch b.Type {
+	case models.FieldRating:
+		if lowered(a.Max, b.Max) {
+			out = append(out, "rating scale shrank")
+		}
+	case models.FieldNumber, models.FieldSlider:
+		if raised(a.Min, b.Min) {
+			out = append(out, "minimum raised")
+		}
+		if lowered(a.Max, b.Max) {
+			out = append(out, "maximum lowered")
+		}
+		if b.Integer && !a.Integer {
+			out = append(out, "now whole numbers only")
+		}
+		if !stepCovers(a, b) {
+			out = append(out, "step grid changed")
+		}
+	case models.FieldDate, models.FieldTime, models.FieldDateTime:
+		if b.Earliest != "" && (a.Earliest == "" || temporalAfter(b.Type, b.Earliest, a.Earliest)) {
+			out = append(out, "earliest allowed value moved later")
+		}
+		if b.Latest != "" && (a.Latest == "" || temporalAfter(b.Type, a.Latest, b.Latest)) {
+			out = append(out, "latest allowed value moved earlier")
+		}
+	case models.FieldText:
+		if b.MinLength > a.MinLength {
+			out = append(out, "minimum length raised")
+		}
+		if b.MaxLength > 0 && (a.MaxLength == 0 || b.MaxLength < a.MaxLength) {
+			out = append(out, "maximum length lowered")
+		}
+		if b.Pattern != "" && b.Pattern != a.Pattern {
+			out = append(out, "pattern added or changed")
+		}
+		if !singleLine(a) && singleLine(b) {
+			out = append(out, "line breaks no longer allowed")
+		}
+	case models.FieldCheckbox:
+		if b.MinSelected > a.MinSelected {
+			out = append(out, "minimum selections raised")
+		}
+		if b.MaxSelected > 0 && (a.MaxSelected == 0 || b.MaxSelected < a.MaxSelected) {
+			out = append(out, "maximum selections lowered")
+		}
+	}
+	switch b.Type {
+	case models.FieldMultiple, models.FieldCheckbox, models.FieldDropdown:
+		if a.AllowOther && !b.AllowOther {
+			out = append(out, "free-text \"other\" answers no longer allowed")
+		}
+	}
+	return out
+}
+
+// raised reports whether the lower bound to is stricter than from; a nil
+// bound is no bound at all.
+func raised(from, to *float64) bool {
+	return to != nil && (from == nil || *to > *from)
+}
+
+// lowered reports whether the upper bound to is stricter than from.
+func lowered(from, to *float64) bool {
+	return to != nil && (from == nil || *to < *from)
+}
+
+// stepCovers reports whether every value on a's step grid is also on b's:
+// b's step must divide a's and both grids must share a point.
+func stepCovers(a, b *models.FormField) bool {
+	if b.Step <= 0 {
+		return true
+	}
+	if a.Step <= 0 {
+		return false
+	}
+	base := func(f *models.FormField) float64 {
+		if f.Min != nil {
+			return *f.Min
+		}
+		return 0
+	}
+	onGrid := func(x float64) bool {
+		k := x / b.Step
+		return math.Abs(k-math.Round(k)) <= stepTolerance*math.Max(1, math.Abs(k))
+	}
+	return onGrid(a.Step) && onGrid(base(a)-base(b))
+}
+
+// temporalAfter reports whether x lies after y; unparsable values never do.
+func temporalAfter(t models.FieldType, x, y string) bool {
+	u, err1 := parseTemporal(t, x)
+	v, err2 := parseTemporal(t, y)
+	return err1 == nil && err2 == nil && u.After(v)
+}
+
+// missing lists the entries of from that to no longer has.
+func missing(from, to []string) []string {
+	var out []string
+	for _, s := range from {
+		if !contains(to, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// keptOrder lists the options of opts that other still has, in opts' order.
+func keptOrder(opts, other []string) []string {
+	out := []string{}
+	for _, o := range opts {
+		if contains(other, o) {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// diffSettings compares every remaining attribute generically so that type
+// specific settings are reported without listing them here one by one.
+func diffSettings(a, b *models.FormField) map[string]Change {
+	am, bm := fieldSettings(a), fieldSettings(b)
+	out := map[string]Change{}
+	for k, av := range am {
+		if bv, ok := bm[k]; !ok || !reflect.DeepEqual(av, bv) {
+			out[k] = Change{From: av, To: bm[k]}
+		}
+	}
+	for k, bv := range bm {
+		if _, ok := am[k]; !ok {
+			out[k] = Change{From: nil, To: bv}
+		}
+	}
+	return out
+}
+
+func fieldSettings(f *models.FormField) map[string]interface{} {
+	raw, _ := json.Marshal(f)
+	m := map[string]interface{}{}
+	_ = json.Unmarshal(raw, &m)
+	for _, k := range []string{"id", "label", "type", "required", "options", "showIf"} {
+		delete(m, k)
+	}
+	return m
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func revision(n int, fields ...models.FormField) *models.FormRevision {
+	return &models.FormRevision{FormID: "f", Revision: n, Title: "T", Status: "draft", Fields: fields}
+}
+
+func TestDiffOptionOrder(t *testing.T) {
+	tests := []struct {
+		typ      models.FieldType
+		from, to []string
+		reorder  bool
+		breaking bool
+	}{
+		{models.FieldMultiple, []string{"A", "B", "C"}, []string{"C", "A", "B"}, true, false},
+		{models.FieldMultiple, []string{"A", "B"}, []string{"A", "B", "C"}, false, false},
+		{models.FieldMultiple, []string{"A", "B", "C"}, []string{"A", "C"}, false, true},
+		{models.FieldLikert, []string{"Bad", "Meh", "Ok", "Good"}, []string{"Good", "Ok", "Meh", "Bad"}, true, true},
+		{models.FieldRanking, []string{"A", "B", "C"}, []string{"B", "A", "C"}, true, true},
+		{models.FieldRanking, []string{"A", "B", "C"}, []string{"A", "B", "C", "D"}, false, false},
+	}
+	for _, tt := range tests {
+		d := diffRevisions(
+			revision(1, models.FormField{ID: "q", Type: tt.typ, Label: "Q", Options: tt.from}),
+			revision(2, models.FormField{ID: "q", Type: tt.typ, Label: "Q", Options: tt.to}),
+		)
+		var fc FieldChange
+		if len(d.Changed) == 1 {
+			fc = d.Changed[0]
+		}
+		if (fc.OptionsReordered != nil) != tt.reorder || d.Breaking != tt.breaking {
+			t.Errorf("%s %v -> %v: reordered=%v breaking=%v, want %v %v",
+				tt.typ, tt.from, tt.to, fc.OptionsReordered, d.Breaking, tt.reorder, tt.breaking)
+		}
+	}
+}
+
+func TestDiffReorderReportsKeptOptions(t *testing.T) {
+	d := diffRevisions(
+		revision(1, models.FormField{ID: "q", Type: models.FieldCheckbox, Label: "Q", Options: []string{"A", "B", "C"}}),
+		revision(2, models.FormField{ID: "q", Type: models.FieldCheckbox, Label: "Q", Options: []string{"D", "C", "A"}}),
+	)
+	fc := d.Changed[0]
+	if fc.OptionsReordered == nil {
+		t.Fatal("reorder not reported")
+	}
+	if !reflect.DeepEqual(fc.OptionsReordered.From, []string{"A", "C"}) || !reflect.DeepEqual(fc.OptionsReordered.To, []string{"C", "A"}) {
+		t.Errorf("reorder = %+v", fc.OptionsReordered)
+	}
+	if !reflect.DeepEqual(fc.OptionsAdded, []string{"D"}) || !reflect.DeepEqual(fc.OptionsRemoved, []string{"B"}) {
+		t.Errorf("added %v removed %v", fc.OptionsAdded, fc.OptionsRemoved)
+	}
+}
+
+func TestDiffFieldsAndMoves(t *testing.T) {
+	a := revision(1,
+		models.FormField{ID: "a", Type: models.FieldText, Label: "A"},
+		models.FormField{ID: "b", Type: models.FieldText, Label: "B"},
+		models.FormField{ID: "c", Type: models.FieldText, Label: "C"},
+		models.FormField{ID: "d", Type: models.FieldRating, Label: "D", Max: floatPtr(10)},
+	)
+	b := revision(2,
+		models.FormField{ID: "c", Type: models.FieldText, Label: "C"},
+		models.FormField{ID: "a", Type: models.FieldText, Label: "A", Required: true},
+		models.FormField{ID: "d", Type: models.FieldRating, Label: "D", Max: floatPtr(5)},
+		models.FormField{ID: "e", Type: models.FieldText, Label: "E"},
+	)
+	d := diffRevisions(a, b)
+
+	if len(d.Added) != 1 || d.Added[0].ID != "e" || len(d.Removed) != 1 || d.Removed[0].ID != "b" {
+		t.Errorf("added %v removed %v", d.Added, d.Removed)
+	}
+	// a and c swapped places; one move explains it
+	if !d.Reordered || len(d.Moved) != 1 {
+		t.Errorf("reordered=%v moved=%v, want one move", d.Reordered, d.Moved)
+	}
+	if !d.Breaking {
+		t.Error("removing a field and shrinking a rating scale must be breaking")
+	}
+	changed := map[string]FieldChange{}
+	for _, fc := range d.Changed {
+		changed[fc.ID] = fc
+	}
+	if fc := changed["a"]; fc.Required == nil || fc.Breaking {
+		t.Errorf("a: %+v", fc)
+	}
+	if fc := changed["d"]; !fc.Breaking || fc.Settings["max"].To != 5.0 {
+		t.Errorf("d: %+v", fc)
+	}
+}
+
+func TestDiffInsertionIsNotAReorder(t *testing.T) {
+	a := revision(1, models.FormField{ID: "a", Type: models.FieldText, Label: "A"}, models.FormField{ID: "b", Type: models.FieldText, Label: "B"})
+	b := revision(2, models.FormField{ID: "x", Type: models.FieldText, Label: "X"}, a.Fields[0], a.Fields[1])
+	if d := diffRevisions(a, b); d.Reordered || d.Breaking || len(d.Changed) != 0 {
+		t.Errorf("diff = %+v", d)
+	}
+}
+
+func TestDiffTightenedConstraints(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name     string
+		from, to models.FormField
+		breaking bool
+	}{
+		{"rating max lowered", models.FormField{Type: models.FieldRating, Max: f(10)}, models.FormField{Type: models.FieldRating, Max: f(5)}, true},
+		{"rating max raised", models.FormField{Type: models.FieldRating, Max: f(5)}, models.FormField{Type: models.FieldRating, Max: f(10)}, false},
+		{"number min raised", models.FormField{Type: models.FieldNumber, Min: f(0)}, models.FormField{Type: models.FieldNumber, Min: f(1)}, true},
+		{"number min added", models.FormField{Type: models.FieldNumber}, models.FormField{Type: models.FieldNumber, Min: f(0)}, true},
+		{"number min dropped", models.FormField{Type: models.FieldNumber, Min: f(0)}, models.FormField{Type: models.FieldNumber}, false},
+		{"number max lowered", models.FormField{Type: models.FieldNumber, Max: f(10)}, models.FormField{Type: models.FieldNumber, Max: f(9)}, true},
+		{"number integer on", models.FormField{Type: models.FieldNumber}, models.FormField{Type: models.FieldNumber, Integer: true}, true},
+		{"number integer off", models.FormField{Type: models.FieldNumber, Integer: true}, models.FormField{Type: models.FieldNumber}, false},
+		{"number step added", models.FormField{Type: models.FieldNumber}, models.FormField{Type: models.FieldNumber, Step: 0.5}, true},
+		{"number step refined", models.FormField{Type: models.FieldNumber, Step: 1}, models.FormField{Type: models.FieldNumber, Step: 0.5}, false},
+		{"number step coarser", models.FormField{Type: models.FieldNumber, Step: 1}, models.FormField{Type: models.FieldNumber, Step: 2}, true},
+		{"number step grid shifted", models.FormField{Type: models.FieldNumber, Step: 1}, models.FormField{Type: models.FieldNumber, Min: f(-0.5), Step: 1}, true},
+		{"number unit changed", models.FormField{Type: models.FieldNumber, Unit: "kg"}, models.FormField{Type: models.FieldNumber, Unit: "lb"}, false},
+		{"slider range narrowed", models.FormField{Type: models.FieldSlider, Min: f(0), Max: f(100), Step: 1}, models.FormField{Type: models.FieldSlider, Min: f(0), Max: f(50), Step: 1}, true},
+		{"slider range widened", models.FormField{Type: models.FieldSlider, Min: f(0), Max: f(100), Step: 1}, models.FormField{Type: models.FieldSlider, Min: f(-100), Max: f(100), Step: 1}, false},
+		{"date earliest later", models.FormField{Type: models.FieldDate, Earliest: "2024-01-01"}, models.FormField{Type: models.FieldDate, Earliest: "2024-06-01"}, true},
+		{"date earliest earlier", models.FormField{Type: models.FieldDate, Earliest: "2024-06-01"}, models.FormField{Type: models.FieldDate, Earliest: "2024-01-01"}, false},
+		{"date latest added", models.FormField{Type: models.FieldDate}, models.FormField{Type: models.FieldDate, Latest: "2024-12-31"}, true},
+		{"time latest earlier", models.FormField{Type: models.FieldTime, Latest: "18:00"}, models.FormField{Type: models.FieldTime, Latest: "17:00"}, true},
+		{"datetime latest later", models.FormField{Type: models.FieldDateTime, Latest: "2024-01-01T00:00:00Z"}, models.FormField{Type: models.FieldDateTime, Latest: "2024-02-01T00:00:00Z"}, false},
+		{"text maxLength lowered", models.FormField{Type: models.FieldText, MaxLength: 100}, models.FormField{Type: models.FieldText, MaxLength: 50}, true},
+		{"text maxLength added", models.FormField{Type: models.FieldText}, models.FormField{Type: models.FieldText, MaxLength: 50}, true},
+		{"text maxLength raised", models.FormField{Type: models.FieldText, MaxLength: 50}, models.FormField{Type: models.FieldText, MaxLength: 100}, false},
+		{"text minLength raised", models.FormField{Type: models.FieldText}, models.FormField{Type: models.FieldText, MinLength: 3}, true},
+		{"text pattern added", models.FormField{Type: models.FieldText}, models.FormField{Type: models.FieldText, Pattern: "[a-z]+"}, true},
+		{"text pattern changed", models.FormField{Type: models.FieldText, Pattern: "[a-z]+"}, models.FormField{Type: models.FieldText, Pattern: "[a-z]*"}, true},
+		{"text pattern removed", models.FormField{Type: models.FieldText, Pattern: "[a-z]+"}, models.FormField{Type: models.FieldText}, false},
+		{"text pattern message changed", models.FormField{Type: models.FieldText, Pattern: "x", PatternMessage: "a"}, models.FormField{Type: models.FieldText, Pattern: "x", PatternMessage: "b"}, false},
+		{"text made single line", models.FormField{Type: models.FieldText}, models.FormField{Type: models.FieldText, Multiline: boolPtr(false)}, true},
+		{"text made multiline", models.FormField{Type: models.FieldText, Multiline: boolPtr(false)}, models.FormField{Type: models.FieldText, Multiline: boolPtr(true)}, false},
+		{"checkbox max lowered", models.FormField{Type: models.FieldCheckbox, MaxSelected: 3}, models.FormField{Type: models.FieldCheckbox, MaxSelected: 2}, true},
+		{"checkbox min raised", models.FormField{Type: models.FieldCheckbox}, models.FormField{Type: models.FieldCheckbox, MinSelected: 1}, true},
+		{"other turned off", models.FormField{Type: models.FieldMultiple, AllowOther: true}, models.FormField{Type: models.FieldMultiple}, true},
+	}
+	for _, tt := range tests {
+		tt.from.ID, tt.from.Label = "q", "Q"
+		tt.to.ID, tt.to.Label = "q", "Q"
+		d := diffRevisions(revision(1, tt.from), revision(2, tt.to))
+		if len(d.Changed) != 1 {
+			t.Errorf("%s: changed = %+v", tt.name, d.Changed)
+			continue
+		}
+		fc := d.Changed[0]
+		if fc.Breaking != tt.breaking || d.Breaking != tt.breaking {
+			t.Errorf("%s: breaking = %v, want %v (reasons %v)", tt.name, fc.Breaking, tt.breaking, fc.Reasons)
+		}
+		if tt.breaking && len(fc.Reasons) == 0 {
+			t.Errorf("%s: breaking without a reason", tt.name)
+		}
+	}
+}
+
+func TestDiffMatrix(t *testing.T) {
+	base := models.FormField{ID: "m", Type: models.FieldMatrix, Label: "M",
+		Rows: []string{"Speed", "Price"}, Columns: []string{"Bad", "Ok", "Good"}}
+	tests := []struct {
+		name     string
+		edit     func(f *models.FormField)
+		breaking bool
+		reason   string
+	}{
+		{"row added", func(f *models.FormField) { f.Rows = append(f.Rows, "Support") }, false, ""},
+		{"row removed", func(f *models.FormField) { f.Rows = []string{"Speed"} }, true, "rows removed: [Price]"},
+		{"column added", func(f *models.FormField) { f.Columns = append(f.Columns, "Great") }, false, ""},
+		{"column removed", func(f *models.FormField) { f.Columns = []string{"Bad", "Good"} }, true, "columns removed: [Ok]"},
+		{"multi select", func(f *models.FormField) { f.MultiSelect = true }, true, ""},
+	}
+	for _, tt := range tests {
+		next := base
+		next.Rows = append([]string{}, base.Rows...)
+		next.Columns = append([]string{}, base.Columns...)
+		tt.edit(&next)
+		d := diffRevisions(revision(1, base), revision(2, next))
+		if len(d.Changed) != 1 || d.Breaking != tt.breaking {
+			t.Errorf("%s: breaking = %v, want %v (%+v)", tt.name, d.Breaking, tt.breaking, d.Changed)
+			continue
+		}
+		if tt.reason != "" && !reflect.DeepEqual(d.Changed[0].Reasons, []string{tt.reason}) {
+			t.Errorf("%s: reasons = %v, want %q", tt.name, d.Changed[0].Reasons, tt.reason)
+		}
+	}
+}
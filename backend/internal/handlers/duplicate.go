@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+type duplicateReq struct {
+	Title            string `json:"title"`
+	IncludeResponses bool   `json:"includeResponses"`
+}
+
+// DuplicateForm copies a form into a new draft owned by the caller. Field IDs
+// are regenerated and every ShowIf and jump is pointed at the new IDs;
+// responses are copied (without respondent identity) only when
+// includeResponses is set, each with its own copy of the uploaded files.
+func (h *FormHandler) DuplicateForm(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	src, err := loadFormFor(c, h.Store, c.Params("id"), userID, models.RoleEditor)
+	if err != nil {
+		return err
+	}
+	if src.DeletedAt != 0 {
+		return errInTrash
+	}
+
+	var in duplicateReq
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&in); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+	if c.QueryBool("includeResponses") {
+		in.IncludeResponses = true
+	}
+
+	fields, idMap := cloneFields(src.Fields)
+	dup := models.Form{
+		ID:       uuid.NewString(),
+		Title:    strings.TrimSpace(in.Title),
+		Fields:   fields,
+		Status:   "draft",
+		OwnerID:  userID,
+		Revision: 1,
+	}
+	if dup.Title == "" {
+		dup.Title = src.Title + " (copy)"
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+
+	if err := h.Store.CreateForm(ctx, &dup); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if err := recordRevision(ctx, h.Store, &dup, userID); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if in.IncludeResponses {
+		resps, err := h.Store.ListResponses(ctx, src.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		fileFields := map[string]bool{}
+		for _, f := range src.Fields {
+			if f.Type == models.FieldFile {
+				fileFields[f.ID] = true
+			}
+		}
+		for _, r := range resps {
+			cp := models.Response{
+				ID:           uuid.NewString(),
+				FormID:       dup.ID,
+				FormRevision: dup.Revision,
+				Answers:      make(map[string]interface{}, len(r.Answers)),
+				Created:      r.Created,
+			}
+			for k, v := range r.Answers {
+				nk, ok := idMap[k]
+				if !ok {
+					continue
+				}
+				if fileFields[k] {
+					// the copy gets its own files, owned by the new form
+					if v, err = copyUpload(ctx, h.Store, h.Blobs, v, dup.ID, nk, cp.ID); err != nil {
+						return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+					}
+					if v == nil {
+						continue
+					}
+				}
+				cp.Answers[nk] = v
+			}
+			if err := h.Store.CreateResponse(ctx, &cp); err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+			}
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dup)
+}
+
+// cloneFields deep-copies fields under fresh IDs and returns the old->new
+// mapping used to rewrite references.
+func cloneFields(src []models.FormField) ([]models.FormField, map[string]string) {
+	idMap := make(map[string]string, len(src))
+	// the old IDs are taken too, so no reference can end up ambiguous
+	taken := make(map[string]bool, 2*len(src))
+	for _, f := range src {
+		taken[f.ID] = true
+	}
+	for _, f := range src {
+		idMap[f.ID] = newFieldID(taken)
+	}
+
+	out := make([]models.FormField, len(src))
+	for i, f := range src {
+		f.ID = idMap[f.ID]
+		f.Options = copyStrings(f.Options)
+		f.Rows = copyStrings(f.Rows)
+		f.Columns = copyStrings(f.Columns)
+		f.Accept = copyStrings(f.Accept)
+		f.Extensions = copyStrings(f.Extensions)
+		f.Min = copyFloat(f.Min)
+		f.Max = copyFloat(f.Max)
+		f.Multiline = copyBool(f.Multiline)
+		if f.ShowIf != nil {
+			cond := *f.ShowIf
+			if nid, ok := idMap[cond.FieldID]; ok {
+				cond.FieldID = nid
+			}
+			f.ShowIf = &cond
+		}
+		if f.Jumps != nil {
+			jumps := make([]models.JumpRule, len(f.Jumps))
+			for k, j := range f.Jumps {
+				if nid, ok := idMap[j.If.FieldID]; ok {
+					j.If.FieldID = nid
+				}
+				if nid, ok := idMap[j.To]; ok {
+					j.To = nid
+				}
+				jumps[k] = j
+			}
+			f.Jumps = jumps
+		}
+		out[i] = f
+	}
+	return out, idMap
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+func copyFloat(p *float64) *float64 {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+func copyBool(p *bool) *bool {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// newFieldID mirrors the "q-xxxxxxxx" IDs the builder generates client-side,
+// drawing again until the ID is not in taken, and then adds it there.
+func newFieldID(taken map[string]bool) string {
+	for {
+		id := "q-" + strings.ReplaceAll(uuid.NewString(), "-", "")[:8]
+		if !taken[id] {
+			taken[id] = true
+			return id
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func TestCloneFieldsDeepCopies(t *testing.T) {
+	src := []models.FormField{
+		{ID: "a", Type: models.FieldMultiple, Options: []string{"x", "y"}},
+		{ID: "m", Type: models.FieldMatrix, Rows: []string{"r"}, Columns: []string{"c"},
+			ShowIf: &models.ShowIf{FieldID: "a", Operator: models.OpEq, Value: "x"}},
+		{ID: "n", Type: models.FieldNumber, Min: floatPtr(1), Max: floatPtr(9)},
+		{ID: "f", Type: models.FieldFile, Accept: []string{"image/*"}, Extensions: []string{".png"}},
+		{ID: "p", Type: models.FieldPageBreak, Jumps: []models.JumpRule{
+			{If: models.ShowIf{FieldID: "a", Operator: models.OpEq, Value: "y"}, To: models.JumpToEnd},
+		}},
+		{ID: "t", Type: models.FieldText, Multiline: boolPtr(false)},
+	}
+	out, idMap := cloneFields(src)
+
+	for i := range out {
+		if out[i].ID == src[i].ID || out[i].ID != idMap[src[i].ID] {
+			t.Errorf("field %d id = %q, want the remapped id", i, out[i].ID)
+		}
+	}
+	if out[1].ShowIf.FieldID != out[0].ID || out[4].Jumps[0].If.FieldID != out[0].ID {
+		t.Error("conditions were not pointed at the new ids")
+	}
+
+	out[0].Options[0] = "changed"
+	out[1].Rows[0] = "changed"
+	out[1].Columns[0] = "changed"
+	out[1].ShowIf.Value = "changed"
+	*out[2].Min = 100
+	*out[2].Max = 100
+	out[3].Accept[0] = "changed"
+	out[3].Extensions[0] = "changed"
+	out[4].Jumps[0].To = "changed"
+	*out[5].Multiline = true
+
+	if src[0].Options[0] != "x" || src[1].Rows[0] != "r" || src[1].Columns[0] != "c" ||
+		src[1].ShowIf.Value != "x" || *src[2].Min != 1 || *src[2].Max != 9 ||
+		src[3].Accept[0] != "image/*" || src[3].Extensions[0] != ".png" ||
+		src[4].Jumps[0].To != models.JumpToEnd || src[1].ShowIf.FieldID != "a" || *src[5].Multiline {
+		t.Errorf("editing the clone changed the source: %+v", src)
+	}
+}
+
+func TestNewFieldIDSkipsTaken(t *testing.T) {
+	src := make([]models.FormField, 500)
+	for i := range src {
+		src[i] = models.FormField{ID: newFieldID(map[string]bool{}), Type: models.FieldText}
+	}
+	out, _ := cloneFields(src)
+
+	seen := map[string]bool{}
+	for _, f := range src {
+		seen[f.ID] = true
+	}
+	for _, f := range out {
+		if seen[f.ID] {
+			t.Fatalf("id %q issued twice", f.ID)
+		}
+		seen[f.ID] = true
+	}
+}
+
+func TestDuplicateCopiesFiles(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	src := fileForm(s, owner, "")
+	uploadAndSubmit(t, s, src.ID)
+
+	status, body := s.do("POST", "/api/forms/"+src.ID+"/duplicate", owner, fiber.Map{"includeResponses": true})
+	if status != fiber.StatusCreated {
+		t.Fatalf("duplicate: %d %s", status, body)
+	}
+	dup := decode[models.Form](t, body)
+
+	// the source going away must not take the copy's files with it
+	if status, body := s.do("DELETE", "/api/forms/"+src.ID+"?permanent=true", owner, nil); status != fiber.StatusOK {
+		t.Fatalf("delete source: %d %s", status, body)
+	}
+
+	resps, err := s.store.ListResponses(context.Background(), dup.ID)
+	if err != nil || len(resps) != 1 {
+		t.Fatalf("copied responses = %v, %v", resps, err)
+	}
+	id, ok := fileAnswerID(resps[0].Answers[dup.Fields[0].ID])
+	if !ok {
+		t.Fatalf("copied answer = %v", resps[0].Answers)
+	}
+	up, err := s.store.GetUpload(context.Background(), id)
+	if err != nil || up.FormID != dup.ID || up.FieldID != dup.Fields[0].ID || up.ResponseID != resps[0].ID {
+		t.Fatalf("copied upload = %+v, %v", up, err)
+	}
+	if status, body := s.do("GET", "/api/files/"+id, owner, nil); status != fiber.StatusOK || string(body) != "hello" {
+		t.Errorf("download from the copy: %d %q", status, body)
+	}
+}
+
+func TestDuplicateRejectsTrashedForm(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	f := s.createForm(owner, fiber.Map{"title": "Old"})
+	if status, _ := s.do("DELETE", "/api/forms/"+f.ID, owner, nil); status != fiber.StatusOK {
+		t.Fatal("trash failed")
+	}
+	if status, _ := s.do("POST", "/api/forms/"+f.ID+"/duplicate", owner, nil); status != fiber.StatusConflict {
+		t.Errorf("duplicating a trashed form: %d, want 409", status)
+	}
+}
@@ -10,53 +10,49 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/jung-kurt/gofpdf"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/db"
 	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ExportHandler struct {
-	Store *db.MongoStore
+	Store db.Store
+	// PublicURL is the externally visible origin of the API, used for the
+	// file links in exports; the request's Host header is not trusted.
+	PublicURL string
 }
 
-func NewExportHandler(s *db.MongoStore) *ExportHandler {
-	return &ExportHandler{Store: s}
+func NewExportHandler(s db.Store, publicURL string) *ExportHandler {
+	return &ExportHandler{Store: s, PublicURL: strings.TrimRight(publicURL, "/")}
 }
 
+// ExportResponses downloads the raw responses; members only, regardless of
+// the public results setting.
 func (h *ExportHandler) ExportResponses(c *fiber.Ctx) error {
 	formID := c.Params("id")
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
 	format := strings.ToLower(c.Query("format", "csv"))
 
-	var form models.Form
-	if err := h.Store.Forms.FindOne(c.Context(), bson.M{"_id": formID}).Decode(&form); err != nil {
-		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	form, err := loadFormFor(c, h.Store, formID, userID, models.RoleViewer)
+	if err != nil {
+		return err
 	}
 
-	cur, err := h.Store.Responses.Find(
-		c.Context(),
-		bson.M{"formId": formID},
-		&options.FindOptions{Sort: bson.M{"created": 1}},
-	)
+	resps, err := h.Store.ListResponses(c.Context(), formID)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "db error")
 	}
-	defer cur.Close(c.Context())
 
-	var resps []models.Response
-	for cur.Next(c.Context()) {
-		var r models.Response
-		if err := cur.Decode(&r); err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, "decode error")
-		}
-		resps = append(resps, r)
-	}
+	withFileLinks(form, resps, h.PublicURL)
 
 	filename := sanitizeFilename(fmt.Sprintf("responses-%s-%s", formID, time.Now().Format("20060102-150405")))
 	switch format {
 	case "csv":
-		data, err := h.renderCSV(&form, resps)
+		data, err := h.renderCSV(form, resps)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, "csv render error")
 		}
@@ -65,7 +61,7 @@ func (h *ExportHandler) ExportResponses(c *fiber.Ctx) error {
 		return c.Send(data)
 
 	case "pdf":
-		data, err := h.renderPDF(&form, resps)
+		data, err := h.renderPDF(form, resps)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, "pdf render error")
 		}
@@ -79,12 +75,17 @@ func (h *ExportHandler) ExportResponses(c *fiber.Ctx) error {
 }
 
 func (h *ExportHandler) renderCSV(form *models.Form, resps []models.Response) ([]byte, error) {
+	fields := answerFields(form)
 	var buf bytes.Buffer
 	w := csv.NewWriter(&buf)
 
 	header := []string{"created"}
-	for _, f := range form.Fields {
-		header = append(header, f.Label)
+	for _, f := range fields {
+		if f.Type == models.FieldMatrix {
+			header = append(header, matrixColumnTitles(&f)...)
+			continue
+		}
+		header = append(header, columnTitle(&f))
 	}
 	if err := w.Write(header); err != nil {
 		return nil, err
@@ -93,8 +94,12 @@ func (h *ExportHandler) renderCSV(form *models.Form, resps []models.Response) ([
 	// rows
 	for _, r := range resps {
 		row := []string{time.Unix(r.Created, 0).Format(time.RFC3339)}
-		for _, f := range form.Fields {
-			val := renderAnswerCSV(r.Answers[f.ID])
+		for _, f := range fields {
+			if f.Type == models.FieldMatrix {
+				row = append(row, matrixCellsCSV(&f, r.Answers[f.ID])...)
+				continue
+			}
+			val := renderAnswerCSV(&f, r.Answers[f.ID])
 			row = append(row, val)
 		}
 		if err := w.Write(row); err != nil {
@@ -108,30 +113,42 @@ func (h *ExportHandler) renderCSV(form *models.Form, resps []models.Response) ([
 	return buf.Bytes(), nil
 }
 
-func renderAnswerCSV(v interface{}) string {
+// columnTitle is the export header of a field; units go in the header so
+// that the cells stay plain numbers.
+func columnTitle(f *models.FormField) string {
+	if f.Unit != "" {
+		return fmt.Sprintf("%s (%s)", f.Label, f.Unit)
+	}
+	return f.Label
+}
+
+func renderAnswerCSV(f *models.FormField, v interface{}) string {
+	if f.Type == models.FieldRanking {
+		return renderRanking(v, "; ")
+	}
 	switch x := v.(type) {
 	case nil:
 		return ""
 	case string:
 		return x
+	case bool:
+		return yesNoLabel(x)
 	case float64:
-		return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.4f", x), "0"), ".")
+		return formatNumber(x)
 	case int, int64:
 		return fmt.Sprintf("%v", x)
 	case []string:
 		return strings.Join(x, "; ")
-	case []interface{}:
-		out := make([]string, 0, len(x))
-		for _, e := range x {
-			out = append(out, fmt.Sprintf("%v", e))
-		}
-		return strings.Join(out, "; ")
+	case []interface{}, primitive.A:
+		arr, _ := toStringSlice(x)
+		return strings.Join(arr, "; ")
 	default:
 		return fmt.Sprintf("%v", x)
 	}
 }
 
 func (h *ExportHandler) renderPDF(form *models.Form, resps []models.Response) ([]byte, error) {
+	fields := answerFields(form)
 	pdf := gofpdf.New("P", "mm", "A4", "")
 	pdf.SetTitle("Form Responses", false)
 	pdf.AddPage()
@@ -148,8 +165,8 @@ func (h *ExportHandler) renderPDF(form *models.Form, resps []models.Response) ([
 
 	pdf.SetFont("Helvetica", "B", 11)
 	cols := []string{"Created"}
-	for _, f := range form.Fields {
-		cols = append(cols, f.Label)
+	for _, f := range fields {
+		cols = append(cols, columnTitle(&f))
 	}
 
 	colWidths := autoColumnWidths(pdf, cols, resps, form, 190)
@@ -161,8 +178,8 @@ func (h *ExportHandler) renderPDF(form *models.Form, resps []models.Response) ([
 	pdf.SetFont("Helvetica", "", 10)
 	for _, r := range resps {
 		cells := []string{time.Unix(r.Created, 0).Format("2006-01-02 15:04")}
-		for _, f := range form.Fields {
-			cells = append(cells, renderAnswerPDF(r.Answers[f.ID]))
+		for _, f := range fields {
+			cells = append(cells, renderAnswerPDF(&f, r.Answers[f.ID]))
 		}
 		maxLines := 1
 		lineHeights := make([]int, len(cells))
@@ -205,30 +222,43 @@ func (h *ExportHandler) renderPDF(form *models.Form, resps []models.Response) ([
 	return out.Bytes(), nil
 }
 
-func renderAnswerPDF(v interface{}) string {
+func renderAnswerPDF(f *models.FormField, v interface{}) string {
+	switch f.Type {
+	case models.FieldMatrix:
+		return renderMatrixPDF(f, v)
+	case models.FieldRanking:
+		return renderRanking(v, ", ")
+	case models.FieldLikert:
+		if label, ok := likertLabel(f, v); ok {
+			return label
+		}
+	}
 	switch x := v.(type) {
 	case nil:
 		return ""
 	case string:
+		if isTemporal(f.Type) {
+			return renderTemporalPDF(f.Type, x)
+		}
 		return x
+	case bool:
+		return yesNoLabel(x)
 	case float64:
-		return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.4f", x), "0"), ".")
+		return formatNumber(x)
 	case int, int64:
 		return fmt.Sprintf("%v", x)
 	case []string:
 		return strings.Join(x, ", ")
-	case []interface{}:
-		out := make([]string, 0, len(x))
-		for _, e := range x {
-			out = append(out, fmt.Sprintf("%v", e))
-		}
-		return strings.Join(out, ", ")
+	case []interface{}, primitive.A:
+		arr, _ := toStringSlice(x)
+		return strings.Join(arr, ", ")
 	default:
 		return fmt.Sprintf("%v", x)
 	}
 }
 
 func autoColumnWidths(pdf *gofpdf.Fpdf, header []string, resps []models.Response, form *models.Form, maxWidth float64) []float64 {
+	fields := answerFields(form)
 	n := len(header)
 	widths := make([]float64, n)
 	min := 20.0
@@ -255,8 +285,8 @@ func autoColumnWidths(pdf *gofpdf.Fpdf, header []string, resps []models.Response
 	for idx := 0; idx < limit; idx++ {
 		r := resps[idx]
 		cells := []string{time.Unix(r.Created, 0).Format("2006-01-02 15:04")}
-		for _, f := range form.Fields {
-			cells = append(cells, renderAnswerPDF(r.Answers[f.ID]))
+		for _, f := range fields {
+			cells = append(cells, renderAnswerPDF(&f, r.Answers[f.ID]))
 		}
 		for i, txt := range cells {
 			if w := measure(txt); w > widths[i] {
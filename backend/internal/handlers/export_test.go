@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func TestExportResponses(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	viewer := s.user("viewer@x.io")
+	f := s.createForm(owner, fiber.Map{"title": "Survey", "status": "published", "fields": []models.FormField{
+		{ID: "name", Type: models.FieldText, Label: "Name"},
+		{ID: "tags", Type: models.FieldCheckbox, Label: "Tags", Options: []string{"a", "b"}},
+		{ID: "m", Type: models.FieldMatrix, Label: "Rate", Rows: []string{"Food", "Service"}, Columns: []string{"Bad", "Good"}},
+	}})
+	s.do("PUT", "/api/forms/"+f.ID+"/collaborators", owner, fiber.Map{"email": "viewer@x.io", "role": "viewer"})
+	s.submitAll(f.ID, fiber.Map{"name": "Ann", "tags": []string{"a", "b"}, "m": fiber.Map{"Service": "Good"}})
+	path := "/api/forms/" + f.ID + "/export"
+
+	status, body := s.do("GET", path, viewer, nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("csv: %d %s", status, body)
+	}
+	records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("csv has %d records, want header and one row", len(records))
+	}
+	if got := strings.Join(records[0][1:], "|"); !strings.Contains(got, "Rate: Food|Rate: Service") {
+		t.Errorf("header = %q", records[0])
+	}
+	if got := strings.Join(records[1][1:], "|"); got != "Ann|a; b||Good" {
+		t.Errorf("row = %q; want one cell per matrix row", records[1])
+	}
+
+	status, body = s.do("GET", path+"?format=pdf", owner, nil)
+	if status != fiber.StatusOK || !bytes.HasPrefix(body, []byte("%PDF")) {
+		t.Errorf("pdf: %d, %.8q", status, body)
+	}
+	if status, _ := s.do("GET", path+"?format=xml", owner, nil); status != fiber.StatusBadRequest {
+		t.Errorf("xml: %d, want 400", status)
+	}
+	if status, _ := s.do("GET", path, "", nil); status != fiber.StatusUnauthorized {
+		t.Errorf("anonymous: %d, want 401", status)
+	}
+}
+
+func TestExportFileLinksUsePublicURL(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	f := fileForm(s, owner, "")
+	id := uploadAndSubmit(t, s, f.ID)
+
+	// a forged Host must not end up in the links handed to the owner
+	req := httptest.NewRequest("GET", "/api/forms/"+f.ID+"/export", nil)
+	req.Host = "evil.example"
+	status, body := s.send(req, owner)
+	if status != fiber.StatusOK {
+		t.Fatalf("csv: %d %s", status, body)
+	}
+	records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("csv = %v, %v", records, err)
+	}
+	if want := testPublicURL + "/api/files/" + id; !contains(records[1], want) {
+		t.Errorf("row = %q, want a link to %s", records[1], want)
+	}
+}
@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/blob"
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/db"
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+const (
+	// MaxUploadSize caps maxFileSize of every file field; the server body
+	// limit is derived from it.
+	MaxUploadSize = 25 << 20
+	// defaultFileSize applies to file fields without maxFileSize.
+	defaultFileSize = 10 << 20
+	// UploadTTL is how long an upload may wait for the response that
+	// claims it before CollectStaleUploads removes it.
+	UploadTTL = 24 * time.Hour
+)
+
+// ScanFunc inspects an upload before it is stored, e.g. by handing it to an
+// antivirus daemon. Returning an error rejects the upload.
+type ScanFunc func(ctx context.Context, u *models.Upload, data []byte) error
+
+type FileHandler struct {
+	Store db.Store
+	Blobs blob.Store
+	Scan  ScanFunc
+}
+
+func NewFileHandler(s db.Store, blobs blob.Store, scan ScanFunc) *FileHandler {
+	return &FileHandler{Store: s, Blobs: blobs, Scan: scan}
+}
+
+func validateFileField(f *models.FormField) error {
+	if f.MaxFileSize == 0 {
+		f.MaxFileSize = defaultFileSize
+	}
+	if f.MaxFileSize < 0 || f.MaxFileSize > MaxUploadSize {
+		return fmt.Errorf("maxFileSize must be between 1 and %d bytes", MaxUploadSize)
+	}
+	for i, t := range f.Accept {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if parts := strings.Split(t, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("accept entry '%s' is not a MIME type", t)
+		}
+		f.Accept[i] = t
+	}
+	for i, ext := range f.Extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext != "" && !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if len(ext) < 2 || strings.ContainsAny(ext[1:], "./\\") {
+			return fmt.Errorf("extension '%s' is not valid", f.Extensions[i])
+		}
+		f.Extensions[i] = ext
+	}
+	return nil
+}
+
+// blobKey is where an upload's contents live in the blob store.
+func blobKey(u *models.Upload) string {
+	return u.FormID + "/" + u.ID
+}
+
+// detectContentType sniffs the content. The extension may only refine a
+// generic result within the same family: text files (CSV, Markdown), zip
+// containers (Office, OpenDocument) and formats the sniffer does not know;
+// it never turns text or unknown bytes into a PDF or an image.
+func detectContentType(name string, data []byte) string {
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	byExt, _, err := mime.ParseMediaType(mime.TypeByExtension(filepath.Ext(name)))
+	if err != nil {
+		return sniffed
+	}
+	switch sniffed {
+	case "text/plain":
+		if strings.HasPrefix(byExt, "text/") {
+			return byExt
+		}
+	case "application/zip":
+		if strings.Contains(byExt, "openxmlformats") || strings.Contains(byExt, "opendocument") || strings.HasSuffix(byExt, "+zip") {
+			return byExt
+		}
+	case "application/octet-stream":
+		if strings.HasPrefix(byExt, "application/") && byExt != "application/pdf" {
+			return byExt
+		}
+	}
+	return sniffed
+}
+
+func acceptsType(accept []string, contentType string) bool {
+	for _, a := range accept {
+		if a == contentType || (strings.HasSuffix(a, "/*") && strings.HasPrefix(contentType, a[:len(a)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// UploadFile stores one file for a file field of a published form. Like
+// submitting, it needs no account; the returned id goes in the answer.
+func (h *FileHandler) UploadFile(c *fiber.Ctx) error {
+	formID := c.Params("id")
+	var field *models.FormField
+	{
+		ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
+		defer cancel()
+		form, err := h.Store.GetForm(ctx, formID)
+		if err != nil || form.DeletedAt != 0 {
+			return fiber.NewError(fiber.StatusNotFound, "form not found")
+		}
+		if form.Status != "published" {
+			return fiber.NewError(fiber.StatusForbidden, "form not published")
+		}
+		for i := range form.Fields {
+			if form.Fields[i].ID == c.Params("fieldId") && form.Fields[i].Type == models.FieldFile {
+				field = &form.Fields[i]
+			}
+		}
+		if field == nil {
+			return fiber.NewError(fiber.StatusNotFound, "file field not found")
+		}
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "multipart field 'file' is required")
+	}
+	limit := field.MaxFileSize
+	if limit == 0 {
+		limit = defaultFileSize
+	}
+	tooLarge := fiber.NewError(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("file must be at most %d bytes", limit))
+	if fh.Size > limit {
+		return tooLarge
+	}
+	name := filepath.Base(strings.ReplaceAll(fh.Filename, "\\", "/"))
+	if name == "." || name == "/" {
+		name = "file"
+	}
+	if len(field.Extensions) > 0 && !contains(field.Extensions, strings.ToLower(filepath.Ext(name))) {
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, fmt.Sprintf("file extension must be one of %v", field.Extensions))
+	}
+
+	src, err := fh.Open()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	data, err := io.ReadAll(io.LimitReader(src, limit+1))
+	src.Close()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if int64(len(data)) > limit {
+		return tooLarge
+	}
+	contentType := detectContentType(name, data)
+	if len(field.Accept) > 0 && !acceptsType(field.Accept, contentType) {
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, fmt.Sprintf("file type %s is not accepted", contentType))
+	}
+
+	up := &models.Upload{
+		ID:          uuid.NewString(),
+		FormID:      formID,
+		FieldID:     field.ID,
+		Name:        name,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Created:     time.Now().Unix(),
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 60*time.Second)
+	defer cancel()
+	if h.Scan != nil {
+		if err := h.Scan(ctx, up, data); err != nil {
+			return fiber.NewError(fiber.StatusUnprocessableEntity, fmt.Sprintf("file rejected: %v", err))
+		}
+	}
+	if err := h.Blobs.Put(ctx, blobKey(up), bytes.NewReader(data), up.Size, up.ContentType); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if err := h.Store.CreateUpload(ctx, up); err != nil {
+		_ = h.Blobs.Delete(ctx, blobKey(up))
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.Status(fiber.StatusCreated).JSON(up)
+}
+
+// DownloadFile serves an uploaded file to members of its form.
+func (h *FileHandler) DownloadFile(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
+	defer cancel()
+	up, err := h.Store.GetUpload(ctx, c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "file not found")
+	}
+	if _, err := loadFormFor(c, h.Store, up.FormID, userID, models.RoleViewer); err != nil {
+		return err
+	}
+
+	// The body is streamed after the handler returns, so the read must not
+	// be tied to a context that is cancelled on return.
+	rc, err := h.Blobs.Get(context.Background(), blobKey(up))
+	if errors.Is(err, blob.ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "file not found")
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	c.Set(fiber.HeaderContentType, up.ContentType)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": up.Name}))
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	return c.SendStream(rc, int(up.Size))
+}
+
+// CollectStaleUploads removes uploads older than ttl that no response has
+// claimed, and returns how many it removed.
+func (h *FileHandler) CollectStaleUploads(ctx context.Context, ttl time.Duration) (int, error) {
+	ups, err := h.Store.DeleteStaleUploads(ctx, time.Now().Add(-ttl).Unix())
+	deleteBlobs(ctx, h.Blobs, ups)
+	return len(ups), err
+}
+
+// purgeUploads removes every upload of a form, records first so that nothing
+// stays downloadable if removing the contents fails halfway.
+func purgeUploads(ctx context.Context, store db.Store, blobs blob.Store, formID string) (int, error) {
+	ups, err := store.DeleteUploads(ctx, formID)
+	if err != nil {
+		return 0, err
+	}
+	deleteBlobs(ctx, blobs, ups)
+	return len(ups), nil
+}
+
+// deleteBlobs is best effort: without its record a blob is unreachable.
+func deleteBlobs(ctx context.Context, blobs blob.Store, ups []models.Upload) {
+	for i := range ups {
+		_ = blobs.Delete(ctx, blobKey(&ups[i]))
+	}
+}
+
+// fileAnswerID reads the upload id of a file answer, given either as the id
+// itself or as the stored object.
+func fileAnswerID(v interface{}) (string, bool) {
+	if s, ok := v.(string); ok {
+		return s, s != ""
+	}
+	if m, ok := toAnswerMap(v); ok {
+		s, ok := m["id"].(string)
+		return s, ok && s != ""
+	}
+	return "", false
+}
+
+// attachUploads swaps the upload ids in a validated response for file
+// references and claims the uploads for it, so that one upload cannot be
+// submitted twice or with another form's field.
+func attachUploads(ctx context.Context, store db.Store, form *models.Form, r *models.Response) error {
+	for _, f := range form.Fields {
+		if f.Type != models.FieldFile {
+			continue
+		}
+		id, ok := fileAnswerID(r.Answers[f.ID])
+		if !ok {
+			continue
+		}
+		up, err := store.GetUpload(ctx, id)
+		if err != nil || up.FormID != form.ID || up.FieldID != f.ID {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("validation error: field '%s' references an unknown file", f.ID))
+		}
+		if err := store.AttachUpload(ctx, id, r.ID); err != nil {
+			if errors.Is(err, db.ErrConflict) {
+				return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("field '%s' file was already submitted", f.ID))
+			}
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		r.Answers[f.ID] = fileAnswer(up)
+	}
+	return nil
+}
+
+// copyUpload duplicates the file behind a stored file answer for a response
+// of another form and returns the answer pointing at the copy, or nil when
+// the original file no longer exists.
+func copyUpload(ctx context.Context, store db.Store, blobs blob.Store, answer interface{}, formID, fieldID, responseID string) (interface{}, error) {
+	id, ok := fileAnswerID(answer)
+	if !ok {
+		return nil, nil
+	}
+	src, err := store.GetUpload(ctx, id)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rc, err := blobs.Get(ctx, blobKey(src))
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	up := *src
+	up.ID = uuid.NewString()
+	up.FormID = formID
+	up.FieldID = fieldID
+	up.ResponseID = responseID
+	if err := blobs.Put(ctx, blobKey(&up), rc, up.Size, up.ContentType); err != nil {
+		return nil, err
+	}
+	if err := store.CreateUpload(ctx, &up); err != nil {
+		_ = blobs.Delete(ctx, blobKey(&up))
+		return nil, err
+	}
+	return fileAnswer(&up), nil
+}
+
+// fileAnswer is how a submitted file is stored in a response.
+func fileAnswer(up *models.Upload) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          up.ID,
+		"name":        up.Name,
+		"size":        up.Size,
+		"contentType": up.ContentType,
+	}
+}
+
+// fileAnalytics counts the files of a field and their total size by content
+// type. It returns the summary and the number of responses that answered.
+func fileAnalytics(f *models.FormField, rows []models.Response) (fiber.Map, int) {
+	byType := map[string]int{}
+	var totalSize int64
+	n := 0
+	for _, r := range rows {
+		m, ok := toAnswerMap(r.Answers[f.ID])
+		if !ok {
+			continue
+		}
+		n++
+		ct, _ := m["contentType"].(string)
+		byType[ct]++
+		if size, ok := toFloat64(m["size"]); ok {
+			totalSize += int64(size)
+		}
+	}
+	return fiber.Map{"type": f.Type, "count": n, "totalSize": totalSize, "byContentType": byType}, n
+}
+
+// withFileLinks replaces file answers with their download URL for exports.
+// The links need the same authorization as the export itself.
+func withFileLinks(form *models.Form, resps []models.Response, baseURL string) {
+	for _, f := range form.Fields {
+		if f.Type != models.FieldFile {
+			continue
+		}
+		for _, r := range resps {
+			if id, ok := fileAnswerID(r.Answers[f.ID]); ok {
+				r.Answers[f.ID] = baseURL + "/api/files/" + id
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func fileForm(s *testServer, owner, id string) models.Form {
+	return s.createForm(owner, fiber.Map{
+		"id":     id,
+		"title":  "Files",
+		"status": "published",
+		"fields": []fiber.Map{{"id": "doc", "type": "file", "label": "Document"}},
+	})
+}
+
+// uploadAndSubmit uploads a file for the doc field, submits it and returns
+// the upload id.
+func uploadAndSubmit(t *testing.T, s *testServer, formID string) string {
+	t.Helper()
+	status, body := s.upload(formID, "doc", "notes.txt", []byte("hello"))
+	if status != fiber.StatusCreated {
+		t.Fatalf("upload: %d %s", status, body)
+	}
+	up := decode[models.Upload](t, body)
+	if status, body := s.do("POST", "/api/forms/"+formID+"/response", "", fiber.Map{"answers": fiber.Map{"doc": up.ID}}); status != fiber.StatusCreated {
+		t.Fatalf("submit: %d %s", status, body)
+	}
+	return up.ID
+}
+
+func TestUploadSubmitDownload(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	f := fileForm(s, owner, "")
+	id := uploadAndSubmit(t, s, f.ID)
+
+	status, body := s.do("GET", "/api/files/"+id, owner, nil)
+	if status != fiber.StatusOK || string(body) != "hello" {
+		t.Errorf("owner download: %d %q", status, body)
+	}
+	if status, _ := s.do("GET", "/api/files/"+id, s.user("other@x.io"), nil); status != fiber.StatusForbidden {
+		t.Errorf("outsider download: %d, want 403", status)
+	}
+	if status, _ := s.do("POST", "/api/forms/"+f.ID+"/response", "", fiber.Map{"answers": fiber.Map{"doc": id}}); status != fiber.StatusConflict {
+		t.Errorf("resubmitting an upload: %d, want 409", status)
+	}
+}
+
+func TestPermanentDeleteRemovesUploads(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	f := fileForm(s, owner, "reused-id")
+	id := uploadAndSubmit(t, s, f.ID)
+	up, err := s.store.GetUpload(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, body := s.do("DELETE", "/api/forms/"+f.ID+"?permanent=true", owner, nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("delete: %d %s", status, body)
+	}
+	if got := decode[fiber.Map](t, body)["files"]; got != 1.0 {
+		t.Errorf("files removed = %v, want 1", got)
+	}
+	if _, err := s.store.GetUpload(context.Background(), id); err == nil {
+		t.Error("upload record survived the permanent delete")
+	}
+	if _, err := s.blobs.Get(context.Background(), blobKey(up)); err == nil {
+		t.Error("blob survived the permanent delete")
+	}
+
+	// re-creating the form under the same id must not bring the file back
+	fileForm(s, owner, "reused-id")
+	if status, _ := s.do("GET", "/api/files/"+id, owner, nil); status != fiber.StatusNotFound {
+		t.Errorf("download after re-creating the form: %d, want 404", status)
+	}
+}
+
+func TestCollectStaleUploads(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	f := fileForm(s, owner, "")
+	claimed := uploadAndSubmit(t, s, f.ID)
+	status, body := s.upload(f.ID, "doc", "left.txt", []byte("unclaimed"))
+	if status != fiber.StatusCreated {
+		t.Fatalf("upload: %d %s", status, body)
+	}
+	unclaimed := decode[models.Upload](t, body)
+
+	h := NewFileHandler(s.store, s.blobs, nil)
+	if n, err := h.CollectStaleUploads(context.Background(), time.Hour); err != nil || n != 0 {
+		t.Fatalf("fresh uploads collected: %d, %v", n, err)
+	}
+	// a negative ttl makes every unclaimed upload stale
+	if n, err := h.CollectStaleUploads(context.Background(), -time.Hour); err != nil || n != 1 {
+		t.Fatalf("collected %d, %v; want 1", n, err)
+	}
+	if _, err := s.store.GetUpload(context.Background(), unclaimed.ID); err == nil {
+		t.Error("unclaimed upload was kept")
+	}
+	if _, err := s.blobs.Get(context.Background(), blobKey(&unclaimed)); err == nil {
+		t.Error("unclaimed blob was kept")
+	}
+	if _, err := s.store.GetUpload(context.Background(), claimed); err != nil {
+		t.Errorf("claimed upload was collected: %v", err)
+	}
+}
+
+func TestFileAnalytics(t *testing.T) {
+	f := &models.FormField{ID: "f", Type: models.FieldFile}
+	rows := []models.Response{
+		{Answers: map[string]interface{}{"f": map[string]interface{}{"contentType": "image/png", "size": 100.0}}},
+		{Answers: map[string]interface{}{"f": map[string]interface{}{"contentType": "image/png", "size": int64(50)}}},
+		{Answers: map[string]interface{}{"f": map[string]interface{}{"contentType": "application/pdf", "size": 7.0}}},
+		{Answers: map[string]interface{}{"f": "unattached-id"}},
+		{Answers: map[string]interface{}{}},
+	}
+	out, n := fileAnalytics(f, rows)
+	if n != 3 || out["totalSize"] != int64(157) {
+		t.Errorf("summary = %v (n=%d)", out, n)
+	}
+	if got := out["byContentType"].(map[string]int); got["image/png"] != 2 || got["application/pdf"] != 1 {
+		t.Errorf("byContentType = %v", got)
+	}
+}
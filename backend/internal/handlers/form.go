@@ -2,24 +2,36 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/blob"
 	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/db"
 	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
 )
 
+var errInTrash = fiber.NewError(fiber.StatusConflict, "form is in the trash; restore it first")
+
+// formIDPattern limits client-chosen form ids to what a generated uuid may
+// contain; ids also key bolt's prefix scans, which a NUL byte would break.
+var formIDPattern = regexp.MustCompile(`^[A-Za-z0-9-]{1,64}$`)
+
 type FormHandler struct {
-	Store *db.MongoStore
+	Store db.Store
+	// Blobs holds uploaded files, removed when a form is deleted for good.
+	Blobs blob.Store
 }
 
-func NewFormHandler(s *db.MongoStore) *FormHandler { return &FormHandler{Store: s} }
+func NewFormHandler(s db.Store, blobs blob.Store) *FormHandler {
+	return &FormHandler{Store: s, Blobs: blobs}
+}
 
 func (h *FormHandler) CreateForm(c *fiber.Ctx) error {
 	userID, _ := c.Locals("userId").(string)
@@ -33,6 +45,8 @@ func (h *FormHandler) CreateForm(c *fiber.Ctx) error {
 	}
 	if body.ID == "" {
 		body.ID = uuid.NewString()
+	} else if !formIDPattern.MatchString(body.ID) {
+		return fiber.NewError(fiber.StatusBadRequest, "id may only contain letters, digits and '-', up to 64 characters")
 	}
 	if body.Title = strings.TrimSpace(body.Title); body.Title == "" {
 		return fiber.NewError(fiber.StatusBadRequest, "title is required")
@@ -45,12 +59,35 @@ func (h *FormHandler) CreateForm(c *fiber.Ctx) error {
 			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("fields[%d]: %v", i, err))
 		}
 	}
+	if err := validatePages(body.Fields); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	// sharing, templates, public results and the trash have their own
+	// endpoints with their own checks
+	body.Collaborators = nil
+	body.Template = ""
+	body.PublicResults = false
+	body.DeletedAt = 0
 
+	// a workspace form belongs to the workspace, not to whoever created it
 	body.OwnerID = userID
+	if body.WorkspaceID != "" {
+		if _, err := loadWorkspaceFor(c, h.Store, body.WorkspaceID, userID, models.RoleEditor); err != nil {
+			return err
+		}
+		body.OwnerID = ""
+	}
+	body.Revision = 1
 
 	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
 	defer cancel()
-	if _, err := h.Store.Forms.InsertOne(ctx, body); err != nil {
+	if err := h.Store.CreateForm(ctx, &body); err != nil {
+		if errors.Is(err, db.ErrDuplicate) {
+			return fiber.NewError(fiber.StatusConflict, "a form with this id already exists")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if err := recordRevision(ctx, h.Store, &body, userID); err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 	return c.Status(fiber.StatusCreated).JSON(body)
@@ -63,14 +100,23 @@ func (h *FormHandler) GetForm(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
 	defer cancel()
 
-	var form models.Form
-	if err := h.Store.Forms.FindOne(ctx, bson.M{"_id": id}).Decode(&form); err != nil {
+	form, err := h.Store.GetForm(ctx, id)
+	if err != nil {
 		return fiber.NewError(fiber.StatusNotFound, "form not found")
 	}
 
-	if form.Status != "published" && form.OwnerID != userID {
+	role := roleOf(ctx, h.Store, form, userID)
+	if form.DeletedAt != 0 && role == "" {
+		return fiber.NewError(fiber.StatusNotFound, "form not found")
+	}
+	if form.Status != "published" && role == "" {
 		return fiber.ErrForbidden
 	}
+	c.Set(fiber.HeaderETag, formETag(form))
+	if role == "" {
+		// respondents see the form, not who it is shared with
+		form.Collaborators = nil
+	}
 	return c.JSON(form)
 }
 
@@ -81,24 +127,21 @@ func (h *FormHandler) UpdateForm(c *fiber.Ctx) error {
 	}
 	id := c.Params("id")
 
-	{
-		ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
-		defer cancel()
-
-		var exist models.Form
-		if err := h.Store.Forms.FindOne(ctx, bson.M{"_id": id}).Decode(&exist); err != nil {
-			return fiber.NewError(fiber.StatusNotFound, "form not found")
-		}
-		if exist.OwnerID != userID {
-			return fiber.ErrForbidden
-		}
+	exist, err := loadFormFor(c, h.Store, id, userID, models.RoleEditor)
+	if err != nil {
+		return err
+	}
+	if exist.DeletedAt != 0 {
+		return errInTrash
+	}
+	if !ifMatches(c, exist) {
+		return preconditionFailed(c, exist)
 	}
 
 	var body models.Form
 	if err := c.BodyParser(&body); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
-	body.ID = id
 	if body.Title = strings.TrimSpace(body.Title); body.Title == "" {
 		return fiber.NewError(fiber.StatusBadRequest, "title is required")
 	}
@@ -110,25 +153,51 @@ func (h *FormHandler) UpdateForm(c *fiber.Ctx) error {
 			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("fields[%d]: %v", i, err))
 		}
 	}
+	if err := validatePages(body.Fields); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
 
-	update := bson.M{
-		"title":   body.Title,
-		"fields":  body.Fields,
-		"status":  body.Status,
-		"ownerId": userID,
+	prev := exist.Revision
+	exist.Title = body.Title
+	exist.Fields = body.Fields
+	exist.Status = body.Status
+	exist.Revision++
+
+	current, err := commitForm(c, h.Store, exist, prev, userID)
+	if current != nil {
+		return preconditionFailed(c, current)
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(exist)
+}
+
+// ListMyForms returns the forms the caller owns or collaborates on plus those
+// of every workspace they belong to.
+func (h *FormHandler) ListMyForms(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
 	}
 
 	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
 	defer cancel()
-	_, err := h.Store.Forms.UpdateByID(ctx, id, bson.M{"$set": update})
+
+	wsIDs, err := workspaceIDs(ctx, h.Store, userID, models.RoleViewer)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
-	body.OwnerID = userID
-	return c.JSON(body)
+	out, err := h.Store.ListForms(ctx, db.FormQuery{MemberID: userID, WorkspaceIDs: wsIDs})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(out)
 }
 
-func (h *FormHandler) ListMyForms(c *fiber.Ctx) error {
+// ListTrash returns the trashed forms the caller may restore or purge: their
+// own and those of workspaces they own.
+func (h *FormHandler) ListTrash(c *fiber.Ctx) error {
 	userID, _ := c.Locals("userId").(string)
 	if userID == "" {
 		return fiber.ErrUnauthorized
@@ -137,44 +206,216 @@ func (h *FormHandler) ListMyForms(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
 	defer cancel()
 
-	cur, err := h.Store.Forms.Find(ctx, bson.M{"ownerId": userID}, &options.FindOptions{
-		Sort: bson.M{"_id": -1},
-	})
+	wsIDs, err := workspaceIDs(ctx, h.Store, userID, models.RoleOwner)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
-	defer cur.Close(ctx)
+	out, err := h.Store.ListForms(ctx, db.FormQuery{OwnerID: userID, WorkspaceIDs: wsIDs, Trashed: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(out)
+}
 
-	var out []models.Form
-	for cur.Next(ctx) {
-		var f models.Form
-		if err := cur.Decode(&f); err == nil {
-			out = append(out, f)
+// DeleteForm moves a form to the trash. With ?permanent=true it is removed
+// for good together with its responses and uploaded files, or with
+// ?responses=anonymize the responses are kept but stripped of respondent
+// identity; the files go either way.
+func (h *FormHandler) DeleteForm(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	form, err := loadFormFor(c, h.Store, c.Params("id"), userID, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	if !c.QueryBool("permanent") {
+		if form.DeletedAt == 0 {
+			form.DeletedAt = time.Now().Unix()
+			if err := saveFormMeta(c, h.Store, form); err != nil {
+				return err
+			}
 		}
+		return c.JSON(form)
 	}
-	return c.JSON(out)
+
+	mode := c.Query("responses", "delete")
+	if mode != "delete" && mode != "anonymize" {
+		return fiber.NewError(fiber.StatusBadRequest, "responses must be delete or anonymize")
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+
+	var n int
+	if mode == "anonymize" {
+		n, err = h.Store.AnonymizeResponses(ctx, form.ID)
+	} else {
+		n, err = h.Store.DeleteResponses(ctx, form.ID)
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	files, err := purgeUploads(ctx, h.Store, h.Blobs, form.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if err := h.Store.DeleteForm(ctx, form.ID); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(fiber.Map{"id": form.ID, "responses": n, "responsesMode": mode, "files": files})
+}
+
+func (h *FormHandler) RestoreForm(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	form, err := loadFormFor(c, h.Store, c.Params("id"), userID, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+	if form.DeletedAt != 0 {
+		form.DeletedAt = 0
+		if err := saveFormMeta(c, h.Store, form); err != nil {
+			return err
+		}
+	}
+	return c.JSON(form)
+}
+
+// saveFormMeta persists bookkeeping such as DeletedAt or Template without
+// bumping the revision; those are not edits of the form's content.
+func saveFormMeta(c *fiber.Ctx, store db.Store, form *models.Form) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	err := store.UpdateForm(ctx, form, form.Revision)
+	if errors.Is(err, db.ErrConflict) {
+		return fiber.NewError(fiber.StatusConflict, "form was modified concurrently; retry")
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return nil
+}
+
+// commitForm stores f on top of revision prev and snapshots the result. When
+// another save won the race it returns the server's current copy instead.
+func commitForm(c *fiber.Ctx, store db.Store, f *models.Form, prev int, userID string) (*models.Form, error) {
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	err := store.UpdateForm(ctx, f, prev)
+	if errors.Is(err, db.ErrConflict) {
+		current, err := store.GetForm(ctx, f.ID)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		return current, nil
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if err := recordRevision(ctx, store, f, userID); err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	c.Set(fiber.HeaderETag, formETag(f))
+	return nil, nil
+}
+
+func formETag(f *models.Form) string {
+	return fmt.Sprintf(`"%d"`, f.Revision)
+}
+
+// ifMatches reports whether the request's If-Match header (if any) names the
+// form's current ETag. A missing header keeps old clients working.
+func ifMatches(c *fiber.Ctx, f *models.Form) bool {
+	h := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if h == "" || h == "*" {
+		return true
+	}
+	want := formETag(f)
+	for _, tag := range strings.Split(h, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// preconditionFailed rejects a stale write with the server copy so the client
+// can merge and retry with the returned ETag.
+func preconditionFailed(c *fiber.Ctx, current *models.Form) error {
+	c.Set(fiber.HeaderETag, formETag(current))
+	return c.Status(fiber.StatusPreconditionFailed).JSON(current)
 }
 
 func validateField(f *models.FormField) error {
 	if f.ID = strings.TrimSpace(f.ID); f.ID == "" {
 		return fmt.Errorf("id is required")
 	}
-	if f.Label = strings.TrimSpace(f.Label); f.Label == "" {
+	f.Label = strings.TrimSpace(f.Label)
+	if isDisplayOnly(f.Type) {
+		return validateContentBlock(f)
+	}
+	if f.Label == "" {
 		return fmt.Errorf("label is required")
 	}
 	switch f.Type {
 	case models.FieldText:
-	case models.FieldMultiple:
-		if len(f.Options) == 0 {
-			return fmt.Errorf("multiple requires non-empty options")
+		if err := validateTextField(f); err != nil {
+			return err
+		}
+	case models.FieldMultiple, models.FieldCheckbox, models.FieldDropdown:
+		if err := validateChoiceField(f); err != nil {
+			return err
 		}
-	case models.FieldCheckbox:
-		if len(f.Options) == 0 {
-			return fmt.Errorf("checkbox requires non-empty options")
+	case models.FieldYesNo:
+	case models.FieldLikert:
+		if err := validateLikertField(f); err != nil {
+			return err
 		}
 	case models.FieldRating:
-		if f.Max <= 0 {
-			f.Max = 5
+		if f.Max == nil || *f.Max < 1 {
+			f.Max = floatPtr(5)
+		} else if *f.Max != math.Trunc(*f.Max) {
+			return fmt.Errorf("rating max must be a whole number")
+		} else if *f.Max > maxRatingScale {
+			// larger scales saved before the cap already behave as capped
+			f.Max = floatPtr(maxRatingScale)
+		}
+	case models.FieldNumber:
+		if err := validateNumberField(f); err != nil {
+			return err
+		}
+	case models.FieldSlider:
+		if err := validateSliderField(f); err != nil {
+			return err
+		}
+	case models.FieldEmail, models.FieldURL, models.FieldPhone:
+		if err := validateContactField(f); err != nil {
+			return err
+		}
+	case models.FieldDate, models.FieldTime, models.FieldDateTime:
+		if err := validateTemporalBounds(f); err != nil {
+			return err
+		}
+	case models.FieldMatrix:
+		if err := validateMatrixField(f); err != nil {
+			return err
+		}
+	case models.FieldRanking:
+		if err := validateRankingField(f); err != nil {
+			return err
+		}
+	case models.FieldNPS:
+		f.Min, f.Max, f.Step = nil, nil, 0
+	case models.FieldFile:
+		if err := validateFileField(f); err != nil {
+			return err
 		}
 	default:
 		return fmt.Errorf("unknown type: %s", f.Type)
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+// putForm updates a form with the given If-Match header and returns the
+// status, the ETag header and the raw body.
+func (s *testServer) putForm(id, userID, ifMatch string, body fiber.Map) (int, string, []byte) {
+	s.t.Helper()
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest("PUT", "/api/forms/"+id, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	s.authorize(req, userID)
+	res, err := s.app.Test(req, -1)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	defer res.Body.Close()
+	out, err := io.ReadAll(res.Body)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return res.StatusCode, res.Header.Get("ETag"), out
+}
+
+func TestUpdateFormIfMatch(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	f := s.createForm(owner, fiber.Map{"title": "v1"})
+
+	status, etag, body := s.putForm(f.ID, owner, `"1"`, fiber.Map{"title": "v2"})
+	if status != fiber.StatusOK || etag != `"2"` {
+		t.Fatalf("matching If-Match: %d %s %s", status, etag, body)
+	}
+	status, etag, body = s.putForm(f.ID, owner, `"1"`, fiber.Map{"title": "stale"})
+	if got := decode[models.Form](t, body); status != fiber.StatusPreconditionFailed || got.Title != "v2" || etag != `"2"` {
+		t.Errorf("stale If-Match: %d %s %+v; want 412 with the current form", status, etag, got)
+	}
+	if status, _, _ = s.putForm(f.ID, owner, `W/"2", "9"`, fiber.Map{"title": "v3"}); status != fiber.StatusOK {
+		t.Errorf("weak tag in a list: %d, want 200", status)
+	}
+	if status, _, _ = s.putForm(f.ID, owner, "", fiber.Map{"title": "v4"}); status != fiber.StatusOK {
+		t.Errorf("no If-Match: %d, want 200", status)
+	}
+	if status, _, _ = s.putForm(f.ID, owner, "", fiber.Map{"title": "  "}); status != fiber.StatusBadRequest {
+		t.Errorf("blank title: %d, want 400", status)
+	}
+
+	_, body = s.do("GET", "/api/forms/"+f.ID+"/revisions", owner, nil)
+	if revs := decode[[]models.FormRevision](t, body); len(revs) != 4 || revs[3].Title != "v4" {
+		t.Errorf("revisions = %+v, want v1..v4", revs)
+	}
+
+	// restoring an old revision is a new edit on top of the latest
+	status, body = s.do("POST", "/api/forms/"+f.ID+"/revisions/1/restore", owner, nil)
+	if got := decode[models.Form](t, body); status != fiber.StatusOK || got.Title != "v1" || got.Revision != 5 {
+		t.Errorf("restore: %d %+v", status, got)
+	}
+	if status, _ = s.do("POST", "/api/forms/"+f.ID+"/revisions/9/restore", owner, nil); status != fiber.StatusNotFound {
+		t.Errorf("restore missing revision: %d, want 404", status)
+	}
+}
+
+func TestTrashAndRestore(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	f := s.createForm(owner, fiber.Map{"title": "Survey", "status": "published"})
+	path := "/api/forms/" + f.ID
+
+	if status, body := s.do("DELETE", path, owner, nil); status != fiber.StatusOK {
+		t.Fatalf("trash: %d %s", status, body)
+	}
+	if status, _ := s.do("GET", path, "", nil); status != fiber.StatusNotFound {
+		t.Errorf("anonymous GET of trashed form: %d, want 404", status)
+	}
+	if status, _ := s.do("PUT", path, owner, fiber.Map{"title": "edit"}); status != fiber.StatusConflict {
+		t.Errorf("edit in trash: %d, want 409", status)
+	}
+	_, body := s.do("GET", "/api/my/forms", owner, nil)
+	if forms := decode[[]models.Form](t, body); len(forms) != 0 {
+		t.Errorf("my forms lists trashed form: %v", forms)
+	}
+	_, body = s.do("GET", "/api/my/forms/trash", owner, nil)
+	if forms := decode[[]models.Form](t, body); len(forms) != 1 || forms[0].ID != f.ID {
+		t.Errorf("trash = %v", forms)
+	}
+
+	if status, body := s.do("POST", path+"/restore", owner, nil); status != fiber.StatusOK {
+		t.Fatalf("restore: %d %s", status, body)
+	}
+	if status, _ := s.do("GET", path, "", nil); status != fiber.StatusOK {
+		t.Errorf("anonymous GET after restore: %d, want 200", status)
+	}
+
+	if status, _ := s.do("DELETE", path+"?permanent=true&responses=keep", owner, nil); status != fiber.StatusBadRequest {
+		t.Errorf("unknown responses mode: %d, want 400", status)
+	}
+	if status, body := s.do("DELETE", path+"?permanent=true", owner, nil); status != fiber.StatusOK {
+		t.Fatalf("purge: %d %s", status, body)
+	}
+	if status, _ := s.do("GET", path, owner, nil); status != fiber.StatusNotFound {
+		t.Errorf("GET after purge: %d, want 404", status)
+	}
+}
+
+func TestCreateFormID(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+
+	if f := s.createForm(owner, fiber.Map{"title": "Generated"}); f.ID == "" {
+		t.Error("no id generated")
+	}
+	if f := s.createForm(owner, fiber.Map{"id": "team-survey-2024", "title": "Chosen"}); f.ID != "team-survey-2024" {
+		t.Errorf("id = %q, want the chosen one", f.ID)
+	}
+	if status, body := s.do("POST", "/api/forms", owner, fiber.Map{"id": "team-survey-2024", "title": "Again"}); status != fiber.StatusConflict {
+		t.Errorf("duplicate id: %d %s, want 409", status, body)
+	}
+	for _, id := range []string{"a\x00b", "a/b", "a b", "ü", strings.Repeat("a", 65)} {
+		if status, _ := s.do("POST", "/api/forms", owner, fiber.Map{"id": id, "title": "Bad"}); status != fiber.StatusBadRequest {
+			t.Errorf("id %q: %d, want 400", id, status)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+// defaultLikertLabels is the scale used when a likert field has no options.
+var defaultLikertLabels = []string{"Strongly disagree", "Disagree", "Neither agree nor disagree", "Agree", "Strongly agree"}
+
+// validateLikertField requires 4 to 11 labeled points so that the top and
+// bottom two boxes never overlap.
+func validateLikertField(f *models.FormField) error {
+	if len(f.Options) == 0 {
+		f.Options = append([]string(nil), defaultLikertLabels...)
+	}
+	if len(f.Options) < 4 || len(f.Options) > 11 {
+		return fmt.Errorf("likert scale must have 4 to 11 points")
+	}
+	for i, label := range f.Options {
+		if label = strings.TrimSpace(label); label == "" {
+			return fmt.Errorf("likert labels must not be empty")
+		}
+		// analytics key the distribution by label, so equal labels would merge
+		if contains(f.Options[:i], label) {
+			return fmt.Errorf("likert labels must be unique")
+		}
+		f.Options[i] = label
+	}
+	return nil
+}
+
+// checkLikertAnswer takes the 1-based point of the scale, 1 being the first
+// (most negative) label. The bounds are checked before converting to int so
+// that huge values cannot wrap around into range.
+func checkLikertAnswer(f *models.FormField, v interface{}) (int, error) {
+	n, ok := toFloat64(v)
+	if !ok || n != math.Trunc(n) || n < 1 || n > float64(len(f.Options)) {
+		return 0, fmt.Errorf("field '%s' must be a point from 1 to %d", f.ID, len(f.Options))
+	}
+	return int(n), nil
+}
+
+func likertLabel(f *models.FormField, v interface{}) (string, bool) {
+	n, err := checkLikertAnswer(f, v)
+	if err != nil {
+		return "", false
+	}
+	return f.Options[n-1], true
+}
+
+// likertAnalytics reports the distribution over labels, the mean point and
+// the top-2-box / bottom-2-box shares: the percentage of answers on the two
+// most positive and the two most negative points. It returns the summary and
+// the number of responses that answered.
+func likertAnalytics(f *models.FormField, rows []models.Response) (fiber.Map, int) {
+	points := len(f.Options)
+	dist := make(map[string]int, points)
+	for _, label := range f.Options {
+		dist[label] = 0
+	}
+	sum, n, top, bottom := 0, 0, 0, 0
+	for _, r := range rows {
+		p, err := checkLikertAnswer(f, r.Answers[f.ID])
+		if err != nil || p < 1 || p > points {
+			continue
+		}
+		dist[f.Options[p-1]]++
+		sum += p
+		n++
+		if p > points-2 {
+			top++
+		}
+		if p <= 2 {
+			bottom++
+		}
+	}
+
+	out := fiber.Map{"type": f.Type, "distribution": dist, "top2Box": 0.0, "bottom2Box": 0.0}
+	if n > 0 {
+		out["average"] = float64(sum) / float64(n)
+		out["top2Box"] = 100 * float64(top) / float64(n)
+		out["bottom2Box"] = 100 * float64(bottom) / float64(n)
+	}
+	return out, n
+}
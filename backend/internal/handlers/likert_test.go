@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func likertField() *models.FormField {
+	f := &models.FormField{ID: "q", Type: models.FieldLikert, Label: "Agree?"}
+	if err := validateLikertField(f); err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func TestValidateLikertField(t *testing.T) {
+	tests := []struct {
+		labels []string
+		ok     bool
+	}{
+		{nil, true},
+		{[]string{"No", "Rather not", "Rather", "Yes"}, true},
+		{[]string{"No", "Yes", "No", "Yes"}, false},
+		{[]string{"No", "Meh", "Meh ", "Yes"}, false},
+		{[]string{"No", "", "Rather", "Yes"}, false},
+		{[]string{"A", "B", "C"}, false},
+	}
+	for _, tt := range tests {
+		f := models.FormField{ID: "q", Type: models.FieldLikert, Label: "Agree?", Options: tt.labels}
+		if err := validateLikertField(&f); (err == nil) != tt.ok {
+			t.Errorf("%q: err = %v, want ok=%v", tt.labels, err, tt.ok)
+		}
+	}
+}
+
+func TestCheckLikertAnswer(t *testing.T) {
+	f := likertField()
+	tests := []struct {
+		in   interface{}
+		want int
+		ok   bool
+	}{
+		{1.0, 1, true},
+		{5.0, 5, true},
+		{int64(3), 3, true},
+		{0.0, 0, false},
+		{6.0, 0, false},
+		{2.5, 0, false},
+		{"3", 0, false},
+		{1e300, 0, false},
+		{-1e300, 0, false},
+		{9.3e18, 0, false},
+	}
+	for _, tt := range tests {
+		got, err := checkLikertAnswer(f, tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("checkLikertAnswer(%v) = %d, %v; want %d, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+// A stored out-of-range answer must not crash analytics, which runs on every
+// submission.
+func TestLikertAnalyticsIgnoresOutOfRange(t *testing.T) {
+	f := likertField()
+	rows := []models.Response{
+		{Answers: map[string]interface{}{"q": 1e300}},
+		{Answers: map[string]interface{}{"q": 5.0}},
+		{Answers: map[string]interface{}{"q": 4.0}},
+		{Answers: map[string]interface{}{"q": 1.0}},
+	}
+	out, n := likertAnalytics(f, rows)
+	if n != 3 {
+		t.Fatalf("answered = %d, want 3", n)
+	}
+	dist := out["distribution"].(map[string]int)
+	if dist["Strongly agree"] != 1 || dist["Agree"] != 1 || dist["Strongly disagree"] != 1 {
+		t.Errorf("distribution = %v", dist)
+	}
+	if got := out["average"].(float64); got != 10.0/3 {
+		t.Errorf("average = %v, want %v", got, 10.0/3)
+	}
+	if got := out["top2Box"].(float64); got != 200.0/3 {
+		t.Errorf("top2Box = %v", got)
+	}
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+// validateMatrixField trims rows and columns and rejects duplicates. Rows key
+// the stored answer map, so they may not contain '.' or start with '$'.
+func validateMatrixField(f *models.FormField) error {
+	if len(f.Rows) == 0 || len(f.Columns) == 0 {
+		return fmt.Errorf("matrix requires non-empty rows and columns")
+	}
+	for i, row := range f.Rows {
+		row = strings.TrimSpace(row)
+		if row == "" || contains(f.Rows[:i], row) {
+			return fmt.Errorf("matrix rows must be non-empty and unique")
+		}
+		if strings.Contains(row, ".") || strings.HasPrefix(row, "$") {
+			return fmt.Errorf("matrix row '%s' may not contain '.' or start with '$'", row)
+		}
+		f.Rows[i] = row
+	}
+	for i, col := range f.Columns {
+		col = strings.TrimSpace(col)
+		if col == "" || contains(f.Columns[:i], col) {
+			return fmt.Errorf("matrix columns must be non-empty and unique")
+		}
+		f.Columns[i] = col
+	}
+	return nil
+}
+
+// toAnswerMap reads a nested answer, which arrives as a JSON object and comes
+// back from the stores as a BSON document.
+func toAnswerMap(v interface{}) (map[string]interface{}, bool) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		return x, true
+	case primitive.M:
+		return x, true
+	case primitive.D:
+		return x.Map(), true
+	default:
+		return nil, false
+	}
+}
+
+// matrixSelections lists the columns chosen in one row of a matrix answer.
+func matrixSelections(v interface{}) []string {
+	if s, ok := v.(string); ok {
+		if s == "" {
+			return nil
+		}
+		return []string{s}
+	}
+	arr, _ := toStringSlice(v)
+	return arr
+}
+
+// checkMatrixAnswer validates a matrix answer row by row and returns it with
+// unanswered rows dropped. A required matrix needs every row answered.
+func checkMatrixAnswer(f *models.FormField, v interface{}) (map[string]interface{}, error) {
+	m, ok := toAnswerMap(v)
+	if !ok {
+		return nil, fmt.Errorf("field '%s' must be an object of row answers", f.ID)
+	}
+	out := make(map[string]interface{}, len(m))
+	for row, cell := range m {
+		if !contains(f.Rows, row) {
+			return nil, fmt.Errorf("field '%s' has unknown row '%s'", f.ID, row)
+		}
+		var picked []string
+		if f.MultiSelect {
+			arr, ok := toStringSlice(cell)
+			if !ok {
+				return nil, fmt.Errorf("field '%s' row '%s' must be array of strings", f.ID, row)
+			}
+			picked = arr
+		} else if s, ok := cell.(string); ok {
+			picked = matrixSelections(s)
+		} else if cell != nil {
+			return nil, fmt.Errorf("field '%s' row '%s' must be string", f.ID, row)
+		}
+		for i, col := range picked {
+			if !contains(f.Columns, col) {
+				return nil, fmt.Errorf("field '%s' row '%s' contains invalid column '%s'", f.ID, row, col)
+			}
+			if contains(picked[:i], col) {
+				return nil, fmt.Errorf("field '%s' row '%s' selects '%s' more than once", f.ID, row, col)
+			}
+		}
+		switch {
+		case len(picked) == 0:
+		case f.MultiSelect:
+			out[row] = picked
+		default:
+			out[row] = picked[0]
+		}
+	}
+	if f.Required {
+		for _, row := range f.Rows {
+			if _, ok := out[row]; !ok {
+				return nil, fmt.Errorf("field '%s' row '%s' is required", f.ID, row)
+			}
+		}
+	}
+	return out, nil
+}
+
+// matrixAnalytics counts the columns picked in each row, plus how many
+// responses answered each row. It returns the summary and the number of
+// responses that answered at least one row.
+func matrixAnalytics(f *models.FormField, rows []models.Response) (fiber.Map, int) {
+	table := make(map[string]map[string]int, len(f.Rows))
+	answered := make(map[string]int, len(f.Rows))
+	for _, row := range f.Rows {
+		table[row] = make(map[string]int, len(f.Columns))
+		for _, col := range f.Columns {
+			table[row][col] = 0
+		}
+		answered[row] = 0
+	}
+
+	n := 0
+	for _, r := range rows {
+		m, ok := toAnswerMap(r.Answers[f.ID])
+		if !ok || len(m) == 0 {
+			continue
+		}
+		n++
+		for row, cell := range m {
+			counts, ok := table[row]
+			if !ok {
+				continue
+			}
+			picked := matrixSelections(cell)
+			for _, col := range picked {
+				if _, ok := counts[col]; ok {
+					counts[col]++
+				}
+			}
+			if len(picked) > 0 {
+				answered[row]++
+			}
+		}
+	}
+	return fiber.Map{"type": f.Type, "distribution": table, "answered": answered}, n
+}
+
+// matrixColumnTitles are the export headers of a matrix field, one per row.
+func matrixColumnTitles(f *models.FormField) []string {
+	out := make([]string, len(f.Rows))
+	for i, row := range f.Rows {
+		out[i] = fmt.Sprintf("%s: %s", f.Label, row)
+	}
+	return out
+}
+
+// matrixCellsCSV flattens a matrix answer into one cell per row.
+func matrixCellsCSV(f *models.FormField, v interface{}) []string {
+	m, _ := toAnswerMap(v)
+	out := make([]string, len(f.Rows))
+	for i, row := range f.Rows {
+		out[i] = renderAnswerCSV(f, m[row])
+	}
+	return out
+}
+
+// renderMatrixPDF writes the answered rows of a matrix as "row: column".
+func renderMatrixPDF(f *models.FormField, v interface{}) string {
+	m, _ := toAnswerMap(v)
+	var parts []string
+	for _, row := range f.Rows {
+		if picked := matrixSelections(m[row]); len(picked) > 0 {
+			parts = append(parts, row+": "+strings.Join(picked, ", "))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
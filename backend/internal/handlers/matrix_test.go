@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func matrixField(multi bool) *models.FormField {
+	return &models.FormField{
+		ID:          "m",
+		Type:        models.FieldMatrix,
+		Rows:        []string{"Food", "Service"},
+		Columns:     []string{"Bad", "OK", "Good"},
+		MultiSelect: multi,
+	}
+}
+
+func TestValidateMatrixField(t *testing.T) {
+	f := &models.FormField{Rows: []string{" Food "}, Columns: []string{"Good "}}
+	if err := validateMatrixField(f); err != nil || f.Rows[0] != "Food" || f.Columns[0] != "Good" {
+		t.Errorf("rows %q, columns %q, %v; want trimmed", f.Rows, f.Columns, err)
+	}
+	for _, f := range []models.FormField{
+		{Rows: []string{"A"}},
+		{Rows: []string{"A", " A"}, Columns: []string{"x"}},
+		{Rows: []string{"a.b"}, Columns: []string{"x"}},
+		{Rows: []string{"$a"}, Columns: []string{"x"}},
+		{Rows: []string{"A"}, Columns: []string{"x", "x"}},
+	} {
+		if err := validateMatrixField(&f); err == nil {
+			t.Errorf("accepted rows %q columns %q", f.Rows, f.Columns)
+		}
+	}
+}
+
+func TestCheckMatrixAnswer(t *testing.T) {
+	tests := []struct {
+		name  string
+		multi bool
+		in    interface{}
+		want  map[string]interface{}
+	}{
+		{"single", false, map[string]interface{}{"Food": "Good", "Service": ""}, map[string]interface{}{"Food": "Good"}},
+		{"bson document", false, primitive.D{{Key: "Food", Value: "OK"}}, map[string]interface{}{"Food": "OK"}},
+		{"unknown row", false, map[string]interface{}{"Price": "OK"}, nil},
+		{"unknown column", false, map[string]interface{}{"Food": "Great"}, nil},
+		{"array in single", false, map[string]interface{}{"Food": []interface{}{"OK"}}, nil},
+		{"not an object", false, "Good", nil},
+		{"multi", true, map[string]interface{}{"Food": []interface{}{"OK", "Good"}}, map[string]interface{}{"Food": []string{"OK", "Good"}}},
+		{"multi duplicate", true, map[string]interface{}{"Food": []interface{}{"OK", "OK"}}, nil},
+		{"multi empty row dropped", true, map[string]interface{}{"Food": []interface{}{}}, map[string]interface{}{}},
+	}
+	for _, tt := range tests {
+		got, err := checkMatrixAnswer(matrixField(tt.multi), tt.in)
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("%s: accepted %v", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, %v; want %v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestCheckMatrixAnswerRequired(t *testing.T) {
+	f := matrixField(false)
+	f.Required = true
+	if _, err := checkMatrixAnswer(f, map[string]interface{}{"Food": "OK"}); err == nil {
+		t.Error("required matrix accepted a missing row")
+	}
+	if _, err := checkMatrixAnswer(f, map[string]interface{}{"Food": "OK", "Service": "Bad"}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMatrixAnalytics(t *testing.T) {
+	f := matrixField(true)
+	rows := []models.Response{
+		{Answers: map[string]interface{}{"m": map[string]interface{}{"Food": []interface{}{"OK", "Good"}}}},
+		{Answers: map[string]interface{}{"m": primitive.M{"Food": primitive.A{"Good"}, "Service": primitive.A{"Bad"}}}},
+		{Answers: map[string]interface{}{"m": map[string]interface{}{}}},
+		{Answers: map[string]interface{}{}},
+	}
+	out, n := matrixAnalytics(f, rows)
+	if n != 2 {
+		t.Errorf("answered = %d, want 2", n)
+	}
+	table := out["distribution"].(map[string]map[string]int)
+	if table["Food"]["Good"] != 2 || table["Food"]["OK"] != 1 || table["Service"]["Bad"] != 1 || table["Service"]["Good"] != 0 {
+		t.Errorf("distribution = %v", table)
+	}
+	if got := out["answered"].(map[string]int); got["Food"] != 2 || got["Service"] != 1 {
+		t.Errorf("answered per row = %v", got)
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+// npsTally counts answers on the 0-10 scale: 9-10 promote, 7-8 are passive,
+// 0-6 detract.
+type npsTally struct {
+	promoters, passives, detractors int
+}
+
+func (t *npsTally) add(score float64) {
+	switch {
+	case score >= 9:
+		t.promoters++
+	case score >= 7:
+		t.passives++
+	default:
+		t.detractors++
+	}
+}
+
+func (t npsTally) count() int { return t.promoters + t.passives + t.detractors }
+
+// score is the percentage of promoters minus that of detractors, -100..100.
+func (t npsTally) score() float64 {
+	if t.count() == 0 {
+		return 0
+	}
+	return 100 * float64(t.promoters-t.detractors) / float64(t.count())
+}
+
+type npsPoint struct {
+	Period string  `json:"period"`
+	NPS    float64 `json:"nps"`
+	Count  int     `json:"count"`
+}
+
+// tallyNPS counts the answers of the given nps fields, overall and by the
+// ISO week the response was submitted in (UTC).
+func tallyNPS(fields []*models.FormField, rows []models.Response) (npsTally, map[string]*npsTally) {
+	var overall npsTally
+	weeks := map[string]*npsTally{}
+	for _, r := range rows {
+		y, w := time.Unix(r.Created, 0).UTC().ISOWeek()
+		key := fmt.Sprintf("%d-W%02d", y, w)
+		for _, f := range fields {
+			n, ok := toFloat64(r.Answers[f.ID])
+			if !ok || n < 0 || n > 10 {
+				continue
+			}
+			overall.add(n)
+			if weeks[key] == nil {
+				weeks[key] = &npsTally{}
+			}
+			weeks[key].add(n)
+		}
+	}
+	return overall, weeks
+}
+
+// npsTrend lists the weekly scores in chronological order.
+func npsTrend(weeks map[string]*npsTally) []npsPoint {
+	out := make([]npsPoint, 0, len(weeks))
+	for period, t := range weeks {
+		out = append(out, npsPoint{Period: period, NPS: t.score(), Count: t.count()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Period < out[j].Period })
+	return out
+}
+
+// npsAnalytics summarizes an nps field. It returns the summary and the number
+// of responses that answered.
+func npsAnalytics(f *models.FormField, rows []models.Response) (fiber.Map, int) {
+	dist := make(map[int]int, 11)
+	for i := 0; i <= 10; i++ {
+		dist[i] = 0
+	}
+	for _, r := range rows {
+		if n, ok := toFloat64(r.Answers[f.ID]); ok && n >= 0 && n <= 10 {
+			dist[int(n)]++
+		}
+	}
+
+	t, weeks := tallyNPS([]*models.FormField{f}, rows)
+	return fiber.Map{
+		"type":         f.Type,
+		"distribution": dist,
+		"promoters":    t.promoters,
+		"passives":     t.passives,
+		"detractors":   t.detractors,
+		"nps":          t.score(),
+		"trend":        npsTrend(weeks),
+	}, t.count()
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func npsRow(day string, answers map[string]interface{}) models.Response {
+	t, err := time.Parse("2006-01-02", day)
+	if err != nil {
+		panic(err)
+	}
+	return models.Response{Created: t.Unix(), Answers: answers}
+}
+
+func TestNPSAnalytics(t *testing.T) {
+	f := &models.FormField{ID: "n", Type: models.FieldNPS}
+	rows := []models.Response{
+		npsRow("2024-01-01", map[string]interface{}{"n": 10.0}),
+		npsRow("2024-01-02", map[string]interface{}{"n": 9.0}),
+		npsRow("2024-01-03", map[string]interface{}{"n": 7.0}),
+		npsRow("2024-01-08", map[string]interface{}{"n": 0.0}),
+		npsRow("2024-01-08", map[string]interface{}{"n": 11.0}),
+		npsRow("2024-01-08", map[string]interface{}{}),
+	}
+	out, n := npsAnalytics(f, rows)
+	if n != 4 {
+		t.Errorf("answered = %d, want 4", n)
+	}
+	if out["promoters"] != 2 || out["passives"] != 1 || out["detractors"] != 1 || out["nps"] != 25.0 {
+		t.Errorf("summary = %v", out)
+	}
+	if got := out["distribution"].(map[int]int); len(got) != 11 || got[10] != 1 || got[0] != 1 {
+		t.Errorf("distribution = %v", got)
+	}
+	want := []npsPoint{{Period: "2024-W01", NPS: 200.0 / 3, Count: 3}, {Period: "2024-W02", NPS: -100, Count: 1}}
+	if got := out["trend"].([]npsPoint); !reflect.DeepEqual(got, want) {
+		t.Errorf("trend = %v, want %v", got, want)
+	}
+}
+
+func TestTallyNPSPoolsFields(t *testing.T) {
+	a := &models.FormField{ID: "a", Type: models.FieldNPS}
+	b := &models.FormField{ID: "b", Type: models.FieldNPS}
+	rows := []models.Response{
+		npsRow("2024-01-01", map[string]interface{}{"a": 10.0, "b": 3.0}),
+		npsRow("2024-01-01", map[string]interface{}{"a": 8.0}),
+	}
+	overall, weeks := tallyNPS([]*models.FormField{a, b}, rows)
+	if overall != (npsTally{promoters: 1, passives: 1, detractors: 1}) || overall.score() != 0 {
+		t.Errorf("overall = %+v", overall)
+	}
+	if len(weeks) != 1 || weeks["2024-W01"].count() != 3 {
+		t.Errorf("weeks = %v", weeks)
+	}
+	if (npsTally{}).score() != 0 {
+		t.Error("empty tally has a score")
+	}
+}
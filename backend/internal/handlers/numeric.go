@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+// stepTolerance absorbs float rounding when checking that an answer sits on
+// the field's step grid (0.1 + 0.2 and friends).
+const stepTolerance = 1e-9
+
+func floatPtr(v float64) *float64 { return &v }
+
+// maxRatingScale caps a rating field's max; analytics keeps one bucket per
+// score, so the scale must stay small.
+const maxRatingScale = 100
+
+// ratingMax is the top score of a rating field, 5 unless configured. Forms
+// stored before the cap are clamped to it here, and on their next save.
+func ratingMax(f *models.FormField) int {
+	if f.Max == nil || *f.Max < 1 {
+		return 5
+	}
+	if *f.Max > maxRatingScale {
+		return maxRatingScale
+	}
+	return int(*f.Max)
+}
+
+func validateNumberField(f *models.FormField) error {
+	if f.Min != nil && f.Max != nil && *f.Min > *f.Max {
+		return fmt.Errorf("min must not be greater than max")
+	}
+	if f.Step < 0 {
+		return fmt.Errorf("step must be positive")
+	}
+	if f.Integer && f.Step != 0 && f.Step != math.Trunc(f.Step) {
+		return fmt.Errorf("step must be a whole number for integer fields")
+	}
+	f.Unit = strings.TrimSpace(f.Unit)
+	return nil
+}
+
+// validateSliderField defaults a slider to 0..100 and requires a range to
+// slide over; Step 0 leaves it continuous.
+func validateSliderField(f *models.FormField) error {
+	if f.Min == nil {
+		f.Min = floatPtr(0)
+	}
+	if f.Max == nil {
+		f.Max = floatPtr(100)
+	}
+	if *f.Min >= *f.Max {
+		return fmt.Errorf("min must be less than max")
+	}
+	if err := validateNumberField(f); err != nil {
+		return err
+	}
+	if f.Step > *f.Max-*f.Min {
+		return fmt.Errorf("step must not exceed the slider range")
+	}
+	f.MinLabel = strings.TrimSpace(f.MinLabel)
+	f.MaxLabel = strings.TrimSpace(f.MaxLabel)
+	return nil
+}
+
+// checkNumberAnswer validates a numeric answer against integer, bounds and
+// step settings. The step grid starts at Min, or at 0 without a minimum.
+func checkNumberAnswer(f *models.FormField, v interface{}) (float64, error) {
+	n, ok := toFloat64(v)
+	if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("field '%s' must be number", f.ID)
+	}
+	if f.Integer && n != math.Trunc(n) {
+		return 0, fmt.Errorf("field '%s' must be a whole number", f.ID)
+	}
+	if f.Min != nil && n < *f.Min {
+		return 0, fmt.Errorf("field '%s' must be at least %s", f.ID, formatNumber(*f.Min))
+	}
+	if f.Max != nil && n > *f.Max {
+		return 0, fmt.Errorf("field '%s' must be at most %s", f.ID, formatNumber(*f.Max))
+	}
+	if f.Step > 0 {
+		base := 0.0
+		if f.Min != nil {
+			base = *f.Min
+		}
+		k := (n - base) / f.Step
+		if math.Abs(k-math.Round(k)) > stepTolerance*math.Max(1, math.Abs(k)) {
+			return 0, fmt.Errorf("field '%s' must be in steps of %s", f.ID, formatNumber(f.Step))
+		}
+	}
+	return n, nil
+}
+
+// numericValues collects the numeric answers of a field.
+func numericValues(f *models.FormField, rows []models.Response) []float64 {
+	var out []float64
+	for _, r := range rows {
+		if n, ok := toFloat64(r.Answers[f.ID]); ok {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// numberAnalytics summarizes a number field. It returns the summary and the
+// number of responses that answered.
+func numberAnalytics(f *models.FormField, rows []models.Response) (fiber.Map, int) {
+	values := numericValues(f, rows)
+	out := describeNumbers(values)
+	out["type"] = f.Type
+	if f.Unit != "" {
+		out["unit"] = f.Unit
+	}
+	out["histogram"] = histogram(values, f.Integer)
+	return out, len(values)
+}
+
+// sliderAnalytics summarizes a slider field like a number field, adding the
+// quartiles and the 10th/90th percentiles. It returns the summary and the
+// number of responses that answered.
+func sliderAnalytics(f *models.FormField, rows []models.Response) (fiber.Map, int) {
+	values := numericValues(f, rows)
+	out := describeNumbers(values)
+	out["type"] = f.Type
+	if f.Unit != "" {
+		out["unit"] = f.Unit
+	}
+	if len(values) > 0 {
+		out["percentiles"] = map[string]float64{
+			"p10": percentile(values, 10),
+			"p25": percentile(values, 25),
+			"p50": percentile(values, 50),
+			"p75": percentile(values, 75),
+			"p90": percentile(values, 90),
+		}
+	}
+	// like the step check, the grid starts at 0 when a stored slider has no Min
+	base := 0.0
+	if f.Min != nil {
+		base = *f.Min
+	}
+	whole := f.Integer || (f.Step != 0 && f.Step == math.Trunc(f.Step) && base == math.Trunc(base))
+	out["histogram"] = histogram(values, whole)
+	return out, len(values)
+}
+
+// describeNumbers returns min, max, mean, median and the sample standard
+// deviation of values. It sorts values in place.
+func describeNumbers(values []float64) fiber.Map {
+	out := fiber.Map{"count": len(values)}
+	if len(values) == 0 {
+		return out
+	}
+	sort.Float64s(values)
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	ss := 0.0
+	for _, v := range values {
+		ss += (v - mean) * (v - mean)
+	}
+	stddev := 0.0
+	if len(values) > 1 {
+		stddev = math.Sqrt(ss / float64(len(values)-1))
+	}
+
+	out["min"] = values[0]
+	out["max"] = values[len(values)-1]
+	out["mean"] = mean
+	out["median"] = percentile(values, 50)
+	out["stddev"] = stddev
+	return out
+}
+
+// percentile interpolates linearly between the closest ranks of sorted.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+type histogramBin struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+// histogram bins sorted values into equal-width bins over their range, the
+// bin count following Sturges' rule. Bins are [from, to) except the last,
+// which includes its upper edge; integer data gets whole-number widths.
+func histogram(sorted []float64, integer bool) []histogramBin {
+	if len(sorted) == 0 {
+		return []histogramBin{}
+	}
+	lo, hi := sorted[0], sorted[len(sorted)-1]
+	if lo == hi {
+		return []histogramBin{{From: lo, To: hi, Count: len(sorted)}}
+	}
+
+	bins := int(math.Ceil(math.Log2(float64(len(sorted))))) + 1
+	if bins > 20 {
+		bins = 20
+	}
+	width := (hi - lo) / float64(bins)
+	if integer {
+		width = math.Ceil((hi - lo + 1) / float64(bins))
+		bins = int(math.Ceil((hi - lo + 1) / width))
+	}
+
+	out := make([]histogramBin, bins)
+	for i := range out {
+		out[i].From = lo + float64(i)*width
+		out[i].To = lo + float64(i+1)*width
+	}
+	if !integer {
+		out[bins-1].To = hi
+	}
+	for _, v := range sorted {
+		i := int((v - lo) / width)
+		if i >= bins {
+			i = bins - 1
+		}
+		out[i].Count++
+	}
+	return out
+}
+
+func formatNumber(n float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.4f", n), "0"), ".")
+}
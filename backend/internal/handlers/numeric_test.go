@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func TestValidateRatingMax(t *testing.T) {
+	tests := []struct {
+		max  *float64
+		want int
+		ok   bool
+	}{
+		{nil, 5, true},
+		{floatPtr(0), 5, true},
+		{floatPtr(10), 10, true},
+		{floatPtr(100), 100, true},
+		{floatPtr(101), 100, true},
+		{floatPtr(1e9), 100, true},
+		{floatPtr(1e300), 100, true},
+		{floatPtr(4.5), 0, false},
+	}
+	for _, tt := range tests {
+		f := models.FormField{ID: "r", Type: models.FieldRating, Label: "Rate", Max: tt.max}
+		err := validateField(&f)
+		if (err == nil) != tt.ok {
+			t.Errorf("max %v: err = %v, want ok=%v", tt.max, err, tt.ok)
+			continue
+		}
+		if tt.ok && ratingMax(&f) != tt.want {
+			t.Errorf("max %v: ratingMax = %d, want %d", tt.max, ratingMax(&f), tt.want)
+		}
+	}
+}
+
+func TestRatingMaxClampsStoredForms(t *testing.T) {
+	f := models.FormField{Type: models.FieldRating, Max: floatPtr(1e300)}
+	if got := ratingMax(&f); got != maxRatingScale {
+		t.Errorf("ratingMax = %d, want %d", got, maxRatingScale)
+	}
+
+	// a form stored before the cap still saves, with its scale clamped
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	stored := models.Form{ID: "legacy", Title: "Old", Status: "draft", OwnerID: owner, Revision: 1,
+		Fields: []models.FormField{{ID: "r", Type: models.FieldRating, Label: "Rate", Max: floatPtr(1000)}}}
+	if err := s.store.CreateForm(context.Background(), &stored); err != nil {
+		t.Fatal(err)
+	}
+	status, _, body := s.putForm("legacy", owner, "", fiber.Map{"title": "Old", "fields": stored.Fields})
+	if status != fiber.StatusOK {
+		t.Fatalf("save: %d %s", status, body)
+	}
+	if got := decode[models.Form](t, body); *got.Fields[0].Max != maxRatingScale {
+		t.Errorf("saved max = %v, want %d", *got.Fields[0].Max, maxRatingScale)
+	}
+}
+
+func TestCheckNumberAnswer(t *testing.T) {
+	tests := []struct {
+		name string
+		f    models.FormField
+		in   interface{}
+		ok   bool
+	}{
+		{"plain", models.FormField{}, 3.5, true},
+		{"not a number", models.FormField{}, "3", false},
+		{"integer", models.FormField{Integer: true}, 3.0, true},
+		{"fraction for integer", models.FormField{Integer: true}, 3.5, false},
+		{"below min", models.FormField{Min: floatPtr(1)}, 0.5, false},
+		{"above max", models.FormField{Max: floatPtr(10)}, 10.5, false},
+		{"on step from min", models.FormField{Min: floatPtr(1), Step: 2}, 5.0, true},
+		{"off step from min", models.FormField{Min: floatPtr(1), Step: 2}, 4.0, false},
+		{"float step", models.FormField{Step: 0.1}, 0.3, true},
+		{"off float step", models.FormField{Step: 0.1}, 0.35, false},
+	}
+	for _, tt := range tests {
+		tt.f.ID = "n"
+		if _, err := checkNumberAnswer(&tt.f, tt.in); (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestNumberAnalytics(t *testing.T) {
+	f := &models.FormField{ID: "n", Type: models.FieldNumber, Integer: true, Unit: "kg"}
+	var rows []models.Response
+	for _, v := range []float64{4, 1, 3, 2} {
+		rows = append(rows, models.Response{Answers: map[string]interface{}{"n": v}})
+	}
+	rows = append(rows, models.Response{Answers: map[string]interface{}{}})
+
+	out, n := numberAnalytics(f, rows)
+	if n != 4 {
+		t.Errorf("answered = %d, want 4", n)
+	}
+	if out["min"] != 1.0 || out["max"] != 4.0 || out["mean"] != 2.5 || out["median"] != 2.5 || out["unit"] != "kg" {
+		t.Errorf("summary = %v", out)
+	}
+	total := 0
+	for _, b := range out["histogram"].([]histogramBin) {
+		if b.From != float64(int(b.From)) {
+			t.Errorf("integer histogram has fractional bin %v", b)
+		}
+		total += b.Count
+	}
+	if total != 4 {
+		t.Errorf("histogram counts %d values, want 4", total)
+	}
+}
+
+func TestValidateSliderField(t *testing.T) {
+	f := models.FormField{Type: models.FieldSlider, MinLabel: " low "}
+	if err := validateSliderField(&f); err != nil {
+		t.Fatal(err)
+	}
+	if *f.Min != 0 || *f.Max != 100 || f.MinLabel != "low" {
+		t.Errorf("defaults = %v..%v %q", *f.Min, *f.Max, f.MinLabel)
+	}
+	for _, f := range []models.FormField{
+		{Min: floatPtr(5), Max: floatPtr(5)},
+		{Min: floatPtr(0), Max: floatPtr(10), Step: 11},
+		{Integer: true, Step: 0.5},
+	} {
+		if err := validateSliderField(&f); err == nil {
+			t.Errorf("accepted %+v", f)
+		}
+	}
+}
+
+func TestSliderAnalytics(t *testing.T) {
+	f := &models.FormField{ID: "s", Type: models.FieldSlider, Min: floatPtr(0), Max: floatPtr(100)}
+	var rows []models.Response
+	for i := 0; i <= 10; i++ {
+		rows = append(rows, models.Response{Answers: map[string]interface{}{"s": float64(i * 10)}})
+	}
+	out, n := sliderAnalytics(f, rows)
+	if n != 11 {
+		t.Errorf("answered = %d, want 11", n)
+	}
+	p := out["percentiles"].(map[string]float64)
+	if p["p10"] != 10 || p["p25"] != 25 || p["p50"] != 50 || p["p90"] != 90 {
+		t.Errorf("percentiles = %v", p)
+	}
+
+	out, n = sliderAnalytics(f, nil)
+	if _, ok := out["percentiles"]; ok || n != 0 {
+		t.Errorf("empty slider summary = %v", out)
+	}
+
+	// sliders stored before validation filled in the bounds have none
+	bare := &models.FormField{ID: "s", Type: models.FieldSlider, Step: 5}
+	if out, n := sliderAnalytics(bare, rows); n != 11 || out["histogram"] == nil {
+		t.Errorf("slider without bounds = %v, %d", out, n)
+	}
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+// formPage is a run of fields between page breaks.
+type formPage struct {
+	// BreakID is the page break opening the page, "" for the first page.
+	BreakID string
+	Title   string
+	Fields  []models.FormField
+	// Jumps are the rules of the page break that closes the page.
+	Jumps []models.JumpRule
+}
+
+func formPages(form *models.Form) []formPage {
+	pages := []formPage{{}}
+	for _, f := range form.Fields {
+		if f.Type == models.FieldPageBreak {
+			pages[len(pages)-1].Jumps = f.Jumps
+			pages = append(pages, formPage{BreakID: f.ID, Title: f.Label})
+			continue
+		}
+		pages[len(pages)-1].Fields = append(pages[len(pages)-1].Fields, f)
+	}
+	return pages
+}
+
+// validatePages checks the jump rules of a form: only page breaks carry them,
+// conditions refer to questions before the break, and targets are later page
+// breaks or the end, so routing always moves forward.
+func validatePages(fields []models.FormField) error {
+	for i, f := range fields {
+		if len(f.Jumps) == 0 {
+			continue
+		}
+		if f.Type != models.FieldPageBreak {
+			return fmt.Errorf("fields[%d]: only page breaks can have jumps", i)
+		}
+		for _, j := range f.Jumps {
+			if !answerFieldBefore(fields[:i], j.If.FieldID) {
+				return fmt.Errorf("fields[%d]: jump condition must refer to a question before the page break", i)
+			}
+			if j.To != models.JumpToEnd && !pageBreakAfter(fields[i+1:], j.To) {
+				return fmt.Errorf("fields[%d]: jump target '%s' must be a later page break or '%s'", i, j.To, models.JumpToEnd)
+			}
+		}
+	}
+	return nil
+}
+
+func answerFieldBefore(fields []models.FormField, id string) bool {
+	for _, f := range fields {
+		if f.ID == id && !isDisplayOnly(f.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+func pageBreakAfter(fields []models.FormField, id string) bool {
+	for _, f := range fields {
+		if f.ID == id && f.Type == models.FieldPageBreak {
+			return true
+		}
+	}
+	return false
+}
+
+// routePages follows the jump rules from the first page and returns the
+// indexes of the pages visited, in order. A rule only sees the answers to
+// visible fields on pages already visited, the same as the respondent did.
+func routePages(pages []formPage, answers map[string]interface{}, visible map[string]bool) []int {
+	seen := map[string]interface{}{}
+	var path []int
+	for i := 0; i < len(pages); {
+		path = append(path, i)
+		for _, f := range pages[i].Fields {
+			if v, ok := answers[f.ID]; ok && visible[f.ID] {
+				seen[f.ID] = v
+			}
+		}
+		next := i + 1
+		for _, j := range pages[i].Jumps {
+			if !evalCondition(&j.If, seen) {
+				continue
+			}
+			if j.To == models.JumpToEnd {
+				next = len(pages)
+			}
+			for k := i + 1; k < len(pages); k++ {
+				if pages[k].BreakID == j.To {
+					next = k
+				}
+			}
+			break
+		}
+		i = next
+	}
+	return path
+}
+
+// hideSkippedPages marks the fields on pages the answers jump over as not
+// visible, so they are neither required nor stored.
+func hideSkippedPages(form *models.Form, answers map[string]interface{}, visible map[string]bool) {
+	pages := formPages(form)
+	if len(pages) < 2 {
+		return
+	}
+	onPath := make([]bool, len(pages))
+	for _, i := range routePages(pages, answers, visible) {
+		onPath[i] = true
+	}
+	for i, p := range pages {
+		if onPath[i] {
+			continue
+		}
+		for _, f := range p.Fields {
+			visible[f.ID] = false
+		}
+	}
+}
+
+type validatePageReq struct {
+	Page    int                    `json:"page"`
+	Answers map[string]interface{} `json:"answers"`
+}
+
+// ValidatePage checks the answers on one page (1-based) of a published form
+// and tells the client which page comes next, null meaning the form can be
+// submitted. Answers to earlier pages are needed for conditions and jumps.
+func (h *ResponseHandler) ValidatePage(c *fiber.Ctx) error {
+	var form *models.Form
+	{
+		ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
+		defer cancel()
+		f, err := h.Store.GetForm(ctx, c.Params("id"))
+		if err != nil || f.DeletedAt != 0 {
+			return fiber.NewError(fiber.StatusNotFound, "form not found")
+		}
+		if f.Status != "published" {
+			return fiber.NewError(fiber.StatusForbidden, "form not published")
+		}
+		form = f
+	}
+
+	var in validatePageReq
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if in.Answers == nil {
+		in.Answers = map[string]interface{}{}
+	}
+	pages := formPages(form)
+	if in.Page < 1 || in.Page > len(pages) {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("page must be between 1 and %d", len(pages)))
+	}
+
+	visible := computeVisibility(form, in.Answers)
+	path := routePages(pages, in.Answers, visible)
+	pos := -1
+	for k, i := range path {
+		if i == in.Page-1 {
+			pos = k
+		}
+	}
+	if pos < 0 {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("page %d is skipped by the answers given", in.Page))
+	}
+
+	page := *form
+	page.Fields = pages[in.Page-1].Fields
+	if err := validateAnswers(&page, in.Answers, visible); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("validation error: %v", err))
+	}
+
+	pageAnswers := map[string]interface{}{}
+	for _, f := range page.Fields {
+		if v, ok := in.Answers[f.ID]; ok && visible[f.ID] {
+			pageAnswers[f.ID] = v
+		}
+	}
+	var next interface{}
+	if pos+1 < len(path) {
+		next = path[pos+1] + 1
+	}
+	return c.JSON(fiber.Map{"page": in.Page, "pages": len(pages), "nextPage": next, "answers": pageAnswers})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+// branchingFields is a three-page form: page 1 asks whether the respondent is
+// a customer, customers go on to page 2, others jump straight to page 3.
+func branchingFields() []models.FormField {
+	return []models.FormField{
+		{ID: "customer", Type: models.FieldYesNo, Label: "Customer?", Required: true},
+		{ID: "b1", Type: models.FieldPageBreak, Label: "Customers", Jumps: []models.JumpRule{
+			{If: models.ShowIf{FieldID: "customer", Operator: models.OpEq, Value: false}, To: "b2"},
+		}},
+		{ID: "since", Type: models.FieldText, Label: "Since when?", Required: true},
+		{ID: "b2", Type: models.FieldPageBreak, Label: "Everyone"},
+		{ID: "comment", Type: models.FieldText, Label: "Anything else?"},
+	}
+}
+
+func TestValidatePages(t *testing.T) {
+	if err := validatePages(branchingFields()); err != nil {
+		t.Fatal(err)
+	}
+	jump := func(fieldID, to string) []models.JumpRule {
+		return []models.JumpRule{{If: models.ShowIf{FieldID: fieldID, Operator: models.OpEq, Value: true}, To: to}}
+	}
+	tests := []struct {
+		name   string
+		mutate func(fs []models.FormField)
+	}{
+		{"jump on a question", func(fs []models.FormField) { fs[0].Jumps = jump("customer", "b2") }},
+		{"condition on a later question", func(fs []models.FormField) { fs[1].Jumps = jump("comment", "b2") }},
+		{"condition on a page break", func(fs []models.FormField) { fs[3].Jumps = jump("b1", models.JumpToEnd) }},
+		{"backwards target", func(fs []models.FormField) { fs[3].Jumps = jump("since", "b1") }},
+		{"unknown target", func(fs []models.FormField) { fs[1].Jumps = jump("customer", "nowhere") }},
+	}
+	for _, tt := range tests {
+		fs := branchingFields()
+		tt.mutate(fs)
+		if err := validatePages(fs); err == nil {
+			t.Errorf("%s: accepted", tt.name)
+		}
+	}
+	fs := branchingFields()
+	fs[3].Jumps = jump("since", models.JumpToEnd)
+	if err := validatePages(fs); err != nil {
+		t.Errorf("jump to end: %v", err)
+	}
+}
+
+func TestRoutePages(t *testing.T) {
+	form := &models.Form{Fields: branchingFields()}
+	pages := formPages(form)
+	if len(pages) != 3 || pages[1].BreakID != "b1" || pages[1].Title != "Customers" {
+		t.Fatalf("pages = %+v", pages)
+	}
+	tests := []struct {
+		answers map[string]interface{}
+		want    []int
+	}{
+		{map[string]interface{}{"customer": true}, []int{0, 1, 2}},
+		{map[string]interface{}{"customer": false}, []int{0, 2}},
+		{map[string]interface{}{}, []int{0, 1, 2}},
+	}
+	for _, tt := range tests {
+		vis := computeVisibility(form, tt.answers)
+		if got := routePages(pages, tt.answers, vis); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("routePages(%v) = %v, want %v", tt.answers, got, tt.want)
+		}
+	}
+
+	vis := computeVisibility(form, map[string]interface{}{"customer": false, "since": "2020"})
+	if vis["since"] || !vis["comment"] {
+		t.Errorf("visibility = %v; want the skipped page hidden", vis)
+	}
+}
+
+func TestValidatePageEndpoint(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	f := s.createForm(owner, fiber.Map{"title": "Survey", "status": "published", "fields": branchingFields()})
+	path := "/api/forms/" + f.ID + "/validate-page"
+
+	type result struct {
+		Page     int                    `json:"page"`
+		Pages    int                    `json:"pages"`
+		NextPage *int                   `json:"nextPage"`
+		Answers  map[string]interface{} `json:"answers"`
+	}
+	status, body := s.do("POST", path, "", fiber.Map{"page": 1, "answers": fiber.Map{"customer": false}})
+	if status != fiber.StatusOK {
+		t.Fatalf("page 1: %d %s", status, body)
+	}
+	if r := decode[result](t, body); r.Pages != 3 || r.NextPage == nil || *r.NextPage != 3 {
+		t.Errorf("page 1 = %+v, want next page 3", r)
+	}
+
+	status, body = s.do("POST", path, "", fiber.Map{"page": 3, "answers": fiber.Map{"customer": false}})
+	if r := decode[result](t, body); status != fiber.StatusOK || r.NextPage != nil {
+		t.Errorf("last page = %d %s, want nextPage null", status, body)
+	}
+
+	tests := []struct {
+		name string
+		body fiber.Map
+	}{
+		{"missing required", fiber.Map{"page": 1, "answers": fiber.Map{}}},
+		{"skipped page", fiber.Map{"page": 2, "answers": fiber.Map{"customer": false}}},
+		{"out of range", fiber.Map{"page": 4, "answers": fiber.Map{"customer": true}}},
+		{"required on page 2", fiber.Map{"page": 2, "answers": fiber.Map{"customer": true}}},
+	}
+	for _, tt := range tests {
+		if status, body := s.do("POST", path, "", tt.body); status != fiber.StatusBadRequest {
+			t.Errorf("%s: %d %s, want 400", tt.name, status, body)
+		}
+	}
+}
+
+func TestSubmitDropsSkippedPages(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	f := s.createForm(owner, fiber.Map{"title": "Survey", "status": "published", "fields": branchingFields()})
+
+	status, body := s.do("POST", "/api/forms/"+f.ID+"/response", "", fiber.Map{
+		"answers": fiber.Map{"customer": false, "since": "2020", "comment": "hi"},
+	})
+	if status != fiber.StatusCreated {
+		t.Fatalf("submit: %d %s", status, body)
+	}
+	got := decode[models.Response](t, body)
+	if _, ok := got.Answers["since"]; ok || got.Answers["comment"] != "hi" {
+		t.Errorf("answers = %v; want the skipped page dropped", got.Answers)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func validateRankingField(f *models.FormField) error {
+	if len(f.Options) < 2 {
+		return fmt.Errorf("ranking requires at least two options")
+	}
+	for i, opt := range f.Options {
+		if contains(f.Options[:i], opt) {
+			return fmt.Errorf("ranking options must be unique")
+		}
+	}
+	if f.TopN < 0 {
+		return fmt.Errorf("topN must not be negative")
+	}
+	if f.TopN > len(f.Options) {
+		return fmt.Errorf("topN must not exceed the number of options")
+	}
+	if f.TopN == len(f.Options) {
+		f.TopN = 0
+	}
+	return nil
+}
+
+// rankingPlaces is how many options an answer has to rank.
+func rankingPlaces(f *models.FormField) int {
+	if f.TopN > 0 {
+		return f.TopN
+	}
+	return len(f.Options)
+}
+
+// checkRankingAnswer requires a non-empty ranking to order exactly
+// rankingPlaces distinct options, best first.
+func checkRankingAnswer(f *models.FormField, arr []string) error {
+	for i, item := range arr {
+		if !contains(f.Options, item) {
+			return fmt.Errorf("field '%s' contains invalid option '%s'", f.ID, item)
+		}
+		if contains(arr[:i], item) {
+			return fmt.Errorf("field '%s' ranks '%s' more than once", f.ID, item)
+		}
+	}
+	if places := rankingPlaces(f); len(arr) != places {
+		if f.TopN > 0 {
+			return fmt.Errorf("field '%s' must rank the top %d options", f.ID, places)
+		}
+		return fmt.Errorf("field '%s' must rank all %d options", f.ID, places)
+	}
+	return nil
+}
+
+// rankingAnalytics reports, per option, how often it was ranked, its average
+// place (1 = first), its first-choice count and its Borda score. The Borda
+// count gives n-1 points for first place down to 0 for last among n options;
+// options left out of a top-N ranking score nothing. It returns the summary
+// and the number of responses that answered.
+func rankingAnalytics(f *models.FormField, rows []models.Response) (fiber.Map, int) {
+	points := len(f.Options) - 1
+	ranked := make(map[string]int, len(f.Options))
+	placeSum := make(map[string]int, len(f.Options))
+	first := make(map[string]int, len(f.Options))
+	borda := make(map[string]int, len(f.Options))
+	for _, opt := range f.Options {
+		ranked[opt], first[opt], borda[opt] = 0, 0, 0
+	}
+
+	n := 0
+	for _, r := range rows {
+		arr, ok := toStringSlice(r.Answers[f.ID])
+		if !ok || len(arr) == 0 {
+			continue
+		}
+		n++
+		for i, opt := range arr {
+			if _, known := ranked[opt]; !known {
+				continue
+			}
+			ranked[opt]++
+			placeSum[opt] += i + 1
+			borda[opt] += points - i
+			if i == 0 {
+				first[opt]++
+			}
+		}
+	}
+
+	avgRank := make(map[string]float64, len(f.Options))
+	for _, opt := range f.Options {
+		if ranked[opt] > 0 {
+			avgRank[opt] = float64(placeSum[opt]) / float64(ranked[opt])
+		}
+	}
+	return fiber.Map{
+		"type":        f.Type,
+		"ranked":      ranked,
+		"averageRank": avgRank,
+		"firstChoice": first,
+		"bordaScore":  borda,
+	}, n
+}
+
+// renderRanking writes a ranking as a numbered list, best first.
+func renderRanking(v interface{}, sep string) string {
+	arr, _ := toStringSlice(v)
+	parts := make([]string, len(arr))
+	for i, opt := range arr {
+		parts[i] = fmt.Sprintf("%d. %s", i+1, opt)
+	}
+	return strings.Join(parts, sep)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func TestValidateRankingField(t *testing.T) {
+	f := &models.FormField{Options: []string{"A", "B"}, TopN: 2}
+	if err := validateRankingField(f); err != nil || f.TopN != 0 {
+		t.Errorf("topN = %d, %v; want cleared when it ranks every option", f.TopN, err)
+	}
+	for _, f := range []models.FormField{
+		{Options: []string{"A"}},
+		{Options: []string{"A", "A"}},
+		{Options: []string{"A", "B"}, TopN: -1},
+		{Options: []string{"A", "B"}, TopN: 3},
+	} {
+		if err := validateRankingField(&f); err == nil {
+			t.Errorf("accepted %+v", f)
+		}
+	}
+}
+
+func TestCheckRankingAnswer(t *testing.T) {
+	opts := []string{"A", "B", "C"}
+	tests := []struct {
+		name string
+		topN int
+		in   []string
+		ok   bool
+	}{
+		{"all", 0, []string{"C", "A", "B"}, true},
+		{"partial", 0, []string{"C", "A"}, false},
+		{"duplicate", 0, []string{"A", "A", "B"}, false},
+		{"unknown", 0, []string{"A", "B", "Z"}, false},
+		{"top two", 2, []string{"B", "A"}, true},
+		{"top two of three", 2, []string{"B", "A", "C"}, false},
+	}
+	for _, tt := range tests {
+		f := &models.FormField{ID: "r", Options: opts, TopN: tt.topN}
+		if err := checkRankingAnswer(f, tt.in); (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestRankingAnalytics(t *testing.T) {
+	f := &models.FormField{ID: "r", Type: models.FieldRanking, Options: []string{"A", "B", "C"}, TopN: 2}
+	rows := []models.Response{
+		{Answers: map[string]interface{}{"r": []interface{}{"A", "B"}}},
+		{Answers: map[string]interface{}{"r": []interface{}{"B", "A"}}},
+		{Answers: map[string]interface{}{"r": []interface{}{"A", "C"}}},
+		{Answers: map[string]interface{}{}},
+	}
+	out, n := rankingAnalytics(f, rows)
+	if n != 3 {
+		t.Errorf("answered = %d, want 3", n)
+	}
+	// two points for first place, one for second, none for being left out
+	if got := out["bordaScore"].(map[string]int); got["A"] != 5 || got["B"] != 3 || got["C"] != 1 {
+		t.Errorf("borda = %v", got)
+	}
+	if got := out["firstChoice"].(map[string]int); got["A"] != 2 || got["B"] != 1 || got["C"] != 0 {
+		t.Errorf("firstChoice = %v", got)
+	}
+	avg := out["averageRank"].(map[string]float64)
+	if avg["A"] != 4.0/3 || avg["C"] != 2 {
+		t.Errorf("averageRank = %v", avg)
+	}
+}
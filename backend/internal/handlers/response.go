@@ -4,12 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/db"
@@ -17,24 +17,26 @@ import (
 )
 
 type ResponseHandler struct {
-	Store     *db.MongoStore
+	Store     db.Store
 	Broadcast func(string, []byte)
 }
 
-func NewResponseHandler(s *db.MongoStore, broadcaster func(string, []byte)) *ResponseHandler {
+func NewResponseHandler(s db.Store, broadcaster func(string, []byte)) *ResponseHandler {
 	return &ResponseHandler{Store: s, Broadcast: broadcaster}
 }
 
 func (h *ResponseHandler) SubmitResponse(c *fiber.Ctx) error {
 	formID := c.Params("id")
 
-	var form models.Form
+	var form *models.Form
 	{
 		ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
 		defer cancel()
-		if err := h.Store.Forms.FindOne(ctx, bson.M{"_id": formID}).Decode(&form); err != nil {
+		f, err := h.Store.GetForm(ctx, formID)
+		if err != nil || f.DeletedAt != 0 {
 			return fiber.NewError(fiber.StatusNotFound, "form not found")
 		}
+		form = f
 		if form.Status != "published" {
 			return fiber.NewError(fiber.StatusForbidden, "form not published")
 		}
@@ -49,11 +51,12 @@ func (h *ResponseHandler) SubmitResponse(c *fiber.Ctx) error {
 	}
 	body.ID = uuid.NewString()
 	body.FormID = formID
+	body.FormRevision = form.Revision
 	body.Created = time.Now().Unix()
 
-	visible := computeVisibility(&form, body.Answers)
+	visible := computeVisibility(form, body.Answers)
 
-	if err := validateAnswers(&form, body.Answers, visible); err != nil {
+	if err := validateAnswers(form, body.Answers, visible); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("validation error: %v", err))
 	}
 	for _, f := range form.Fields {
@@ -64,12 +67,15 @@ func (h *ResponseHandler) SubmitResponse(c *fiber.Ctx) error {
 
 	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
 	defer cancel()
-	if _, err := h.Store.Responses.InsertOne(ctx, body); err != nil {
+	if err := attachUploads(ctx, h.Store, form, &body); err != nil {
+		return err
+	}
+	if err := h.Store.CreateResponse(ctx, &body); err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
 	if h.Broadcast != nil {
-		analytics, _ := computeAnalytics(ctx, h.Store, formID, &form)
+		analytics, _ := computeAnalytics(ctx, h.Store, formID, form)
 		msg := fiber.Map{
 			"type":      "response:new",
 			"formId":    formID,
@@ -78,12 +84,19 @@ func (h *ResponseHandler) SubmitResponse(c *fiber.Ctx) error {
 		}
 		b, _ := json.Marshal(msg)
 		h.Broadcast(formID, b)
+		if form.PublicResults && analytics != nil {
+			msg["analytics"] = analytics.publicCopy()
+			b, _ = json.Marshal(msg)
+			h.Broadcast(publicChannel(formID), b)
+		}
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(body)
 }
 
 
+// computeVisibility decides which fields a respondent saw: those whose ShowIf
+// holds, on the pages the jump rules route them through.
 func computeVisibility(form *models.Form, answers map[string]interface{}) map[string]bool {
 	vis := make(map[string]bool, len(form.Fields))
 	for _, f := range form.Fields {
@@ -93,6 +106,7 @@ func computeVisibility(form *models.Form, answers map[string]interface{}) map[st
 		}
 		vis[f.ID] = evalCondition(f.ShowIf, answers)
 	}
+	hideSkippedPages(form, answers, vis)
 	return vis
 }
 
@@ -158,6 +172,11 @@ func arrIncludes(arr interface{}, needle interface{}) bool {
 func numericCompare(a, b interface{}, op string) bool {
 	af, ok1 := toFloat64(a)
 	bf, ok2 := toFloat64(b)
+	if !ok1 || !ok2 {
+		// dates and times compare chronologically
+		af, ok1 = temporalOrdinal(a)
+		bf, ok2 = temporalOrdinal(b)
+	}
 	if !ok1 || !ok2 {
 		return false
 	}
@@ -178,6 +197,10 @@ func numericCompare(a, b interface{}, op string) bool {
 
 func validateAnswers(form *models.Form, ans map[string]interface{}, visible map[string]bool) error {
 	for _, f := range form.Fields {
+		if isDisplayOnly(f.Type) {
+			delete(ans, f.ID)
+			continue
+		}
 		vis := visible[f.ID]
 		v, ok := ans[f.ID]
 
@@ -193,39 +216,112 @@ func validateAnswers(form *models.Form, ans map[string]interface{}, visible map[
 
 		switch f.Type {
 		case models.FieldText:
-			if _, ok := v.(string); !ok {
+			s, ok := v.(string)
+			if !ok {
 				return fmt.Errorf("field '%s' must be string", f.ID)
 			}
-		case models.FieldMultiple:
+			if err := checkTextAnswer(&f, s); err != nil {
+				return err
+			}
+		case models.FieldMultiple, models.FieldDropdown:
 			str, ok := v.(string)
 			if !ok {
 				return fmt.Errorf("field '%s' must be string", f.ID)
 			}
-			if !contains(f.Options, str) {
-				return fmt.Errorf("field '%s' must be one of %v", f.ID, f.Options)
+			if isEmpty(v) {
+				continue
 			}
+			norm, err := checkChoice(&f, str)
+			if err != nil {
+				return err
+			}
+			ans[f.ID] = norm
 		case models.FieldCheckbox:
 			arr, ok := toStringSlice(v)
 			if !ok {
 				return fmt.Errorf("field '%s' must be array of strings", f.ID)
 			}
-			for _, item := range arr {
-				if !contains(f.Options, item) {
-					return fmt.Errorf("field '%s' contains invalid option '%s'", f.ID, item)
-				}
+			if len(arr) == 0 {
+				continue
+			}
+			norm, err := checkCheckboxAnswer(&f, arr)
+			if err != nil {
+				return err
 			}
+			ans[f.ID] = norm
 		case models.FieldRating:
 			n, ok := toFloat64(v)
 			if !ok {
 				return fmt.Errorf("field '%s' must be number", f.ID)
 			}
-			max := f.Max
-			if max <= 0 {
-				max = 5
-			}
+			max := ratingMax(&f)
 			if n < 1 || n > float64(max) {
 				return fmt.Errorf("field '%s' rating must be between 1 and %d", f.ID, max)
 			}
+		case models.FieldNumber, models.FieldSlider:
+			if _, err := checkNumberAnswer(&f, v); err != nil {
+				return err
+			}
+		case models.FieldEmail, models.FieldURL, models.FieldPhone:
+			if isEmpty(v) {
+				continue
+			}
+			norm, err := checkContactAnswer(&f, v)
+			if err != nil {
+				return err
+			}
+			ans[f.ID] = norm
+		case models.FieldDate, models.FieldTime, models.FieldDateTime:
+			if isEmpty(v) {
+				continue
+			}
+			norm, err := checkTemporalAnswer(&f, v)
+			if err != nil {
+				return err
+			}
+			ans[f.ID] = norm
+		case models.FieldMatrix:
+			if isEmpty(v) {
+				continue
+			}
+			norm, err := checkMatrixAnswer(&f, v)
+			if err != nil {
+				return err
+			}
+			ans[f.ID] = norm
+		case models.FieldRanking:
+			arr, ok := toStringSlice(v)
+			if !ok {
+				return fmt.Errorf("field '%s' must be array of strings", f.ID)
+			}
+			if len(arr) == 0 {
+				continue
+			}
+			if err := checkRankingAnswer(&f, arr); err != nil {
+				return err
+			}
+		case models.FieldNPS:
+			n, ok := toFloat64(v)
+			if !ok || n != math.Trunc(n) || n < 0 || n > 10 {
+				return fmt.Errorf("field '%s' must be a whole number from 0 to 10", f.ID)
+			}
+		case models.FieldYesNo:
+			if _, ok := v.(bool); !ok {
+				return fmt.Errorf("field '%s' must be true or false", f.ID)
+			}
+		case models.FieldLikert:
+			if _, err := checkLikertAnswer(&f, v); err != nil {
+				return err
+			}
+		case models.FieldFile:
+			if isEmpty(v) {
+				continue
+			}
+			id, ok := fileAnswerID(v)
+			if !ok {
+				return fmt.Errorf("field '%s' must be the id of an uploaded file", f.ID)
+			}
+			ans[f.ID] = id
 		default:
 			return fmt.Errorf("unknown field type '%s'", f.Type)
 		}
@@ -243,6 +339,8 @@ func isEmpty(v interface{}) bool {
 		return len(t) == 0
 	case []string:
 		return len(t) == 0
+	case map[string]interface{}:
+		return len(t) == 0
 	default:
 		return false
 	}
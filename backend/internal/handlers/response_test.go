@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func TestEvalCondition(t *testing.T) {
+	answers := map[string]interface{}{
+		"name":  " Ann ",
+		"age":   30.0,
+		"tags":  []interface{}{"a", "b"},
+		"day":   "2024-03-01",
+		"since": "09:30",
+	}
+	tests := []struct {
+		field string
+		op    models.ConditionOperator
+		value interface{}
+		want  bool
+	}{
+		{"name", models.OpEq, "Ann", true},
+		{"name", models.OpNe, "Ann", false},
+		{"age", models.OpEq, 30, true},
+		{"age", models.OpGt, 29.5, true},
+		{"age", models.OpLte, 29, false},
+		{"age", models.OpGt, "abc", false},
+		{"tags", models.OpIncludes, "b", true},
+		{"tags", models.OpIncludes, "c", false},
+		{"day", models.OpGte, "2024-02-29", true},
+		{"since", models.OpLt, "10:00", true},
+		{"missing", models.OpNe, "x", false},
+		{"name", "bogus", "Ann", false},
+	}
+	for _, tt := range tests {
+		cond := &models.ShowIf{FieldID: tt.field, Operator: tt.op, Value: tt.value}
+		if got := evalCondition(cond, answers); got != tt.want {
+			t.Errorf("%s %s %v = %v, want %v", tt.field, tt.op, tt.value, got, tt.want)
+		}
+	}
+	if !evalCondition(nil, answers) || !evalCondition(&models.ShowIf{}, answers) {
+		t.Error("an empty condition must hold")
+	}
+}
+
+func conditionalFields() []models.FormField {
+	return []models.FormField{
+		{ID: "pet", Type: models.FieldMultiple, Label: "Pet", Options: []string{"Cat", "Dog"}, Required: true},
+		{ID: "dogName", Type: models.FieldText, Label: "Dog's name", Required: true,
+			ShowIf: &models.ShowIf{FieldID: "pet", Operator: models.OpEq, Value: "Dog"}},
+		{ID: "email", Type: models.FieldEmail, Label: "Email"},
+		{ID: "intro", Type: models.FieldParagraph, Content: "Thanks!"},
+	}
+}
+
+func TestSubmitResponse(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	f := s.createForm(owner, fiber.Map{"title": "Pets", "status": "published", "fields": conditionalFields()})
+	path := "/api/forms/" + f.ID + "/response"
+
+	status, body := s.do("POST", path, "", fiber.Map{"answers": fiber.Map{
+		"pet": "Cat", "dogName": "Rex", "email": "Ann@Example.com", "intro": "x",
+	}})
+	if status != fiber.StatusCreated {
+		t.Fatalf("submit: %d %s", status, body)
+	}
+	got := decode[models.Response](t, body)
+	if _, ok := got.Answers["dogName"]; ok {
+		t.Error("answer to a hidden field was stored")
+	}
+	if _, ok := got.Answers["intro"]; ok {
+		t.Error("answer to a display-only field was stored")
+	}
+	if got.Answers["email"] != "Ann@example.com" || got.FormRevision != f.Revision {
+		t.Errorf("response = %+v", got)
+	}
+
+	tests := []struct {
+		name    string
+		answers fiber.Map
+	}{
+		{"shown and required", fiber.Map{"pet": "Dog"}},
+		{"missing required", fiber.Map{}},
+		{"invalid option", fiber.Map{"pet": "Fish"}},
+		{"wrong type", fiber.Map{"pet": 1}},
+		{"bad email", fiber.Map{"pet": "Cat", "email": "nope"}},
+	}
+	for _, tt := range tests {
+		if status, body := s.do("POST", path, "", fiber.Map{"answers": tt.answers}); status != fiber.StatusBadRequest {
+			t.Errorf("%s: %d %s, want 400", tt.name, status, body)
+		}
+	}
+
+	rows, _ := s.store.ListResponses(context.Background(), f.ID)
+	if len(rows) != 1 {
+		t.Errorf("stored %d responses, want 1", len(rows))
+	}
+}
+
+func TestSubmitResponseNeedsPublishedForm(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	draft := s.createForm(owner, fiber.Map{"title": "Draft"})
+	if status, _ := s.do("POST", "/api/forms/"+draft.ID+"/response", "", fiber.Map{"answers": fiber.Map{}}); status != fiber.StatusForbidden {
+		t.Errorf("draft: %d, want 403", status)
+	}
+
+	live := s.createForm(owner, fiber.Map{"title": "Live", "status": "published"})
+	s.do("DELETE", "/api/forms/"+live.ID, owner, nil)
+	if status, _ := s.do("POST", "/api/forms/"+live.ID+"/response", "", fiber.Map{"answers": fiber.Map{}}); status != fiber.StatusNotFound {
+		t.Errorf("trashed: %d, want 404", status)
+	}
+	if status, _ := s.do("POST", "/api/forms/nope/response", "", fiber.Map{"answers": fiber.Map{}}); status != fiber.StatusNotFound {
+		t.Errorf("missing: %d, want 404", status)
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/db"
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+type RevisionHandler struct {
+	Store db.Store
+}
+
+func NewRevisionHandler(s db.Store) *RevisionHandler { return &RevisionHandler{Store: s} }
+
+func (h *RevisionHandler) ListRevisions(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	form, err := loadFormFor(c, h.Store, c.Params("id"), userID, models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	revs, err := h.Store.ListRevisions(ctx, form.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(revs)
+}
+
+func (h *RevisionHandler) GetRevision(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	form, err := loadFormFor(c, h.Store, c.Params("id"), userID, models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	rev, err := loadRevision(c, h.Store, form.ID, "rev")
+	if err != nil {
+		return err
+	}
+	return c.JSON(rev)
+}
+
+// RestoreRevision copies an old snapshot's title and fields onto the form as
+// a brand new revision; history itself is never rewritten.
+func (h *RevisionHandler) RestoreRevision(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	form, err := loadFormFor(c, h.Store, c.Params("id"), userID, models.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	if form.DeletedAt != 0 {
+		return errInTrash
+	}
+	if !ifMatches(c, form) {
+		return preconditionFailed(c, form)
+	}
+
+	rev, err := loadRevision(c, h.Store, form.ID, "rev")
+	if err != nil {
+		return err
+	}
+
+	prev := form.Revision
+	form.Title = rev.Title
+	form.Fields = rev.Fields
+	form.Revision++
+
+	current, err := commitForm(c, h.Store, form, prev, userID)
+	if current != nil {
+		return preconditionFailed(c, current)
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(form)
+}
+
+func loadRevision(c *fiber.Ctx, store db.Store, formID, param string) (*models.FormRevision, error) {
+	n, err := c.ParamsInt(param)
+	if err != nil || n <= 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid revision")
+	}
+	return fetchRevision(c, store, formID, n)
+}
+
+func fetchRevision(c *fiber.Ctx, store db.Store, formID string, n int) (*models.FormRevision, error) {
+	ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
+	defer cancel()
+
+	rev, err := store.GetRevision(ctx, formID, n)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "revision not found")
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return rev, nil
+}
+
+func recordRevision(ctx context.Context, store db.Store, f *models.Form, authorID string) error {
+	return store.CreateRevision(ctx, &models.FormRevision{
+		FormID:   f.ID,
+		Revision: f.Revision,
+		Title:    f.Title,
+		Fields:   f.Fields,
+		Status:   f.Status,
+		AuthorID: authorID,
+		Created:  time.Now().Unix(),
+	})
+}
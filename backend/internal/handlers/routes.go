@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/middleware"
+)
+
+// Routes holds the handlers served by the API.
+type Routes struct {
+	Auth      *AuthHandler
+	Form      *FormHandler
+	Revision  *RevisionHandler
+	Collab    *CollaboratorHandler
+	Workspace *WorkspaceHandler
+	Response  *ResponseHandler
+	Analytics *AnalyticsHandler
+	Export    *ExportHandler
+	File      *FileHandler
+	Template  *TemplateHandler
+	Stream    *StreamHandler
+}
+
+// RegisterRoutes mounts the API under /api. Sessions and stream tokens are
+// verified with jwtSecret.
+func RegisterRoutes(app *fiber.App, r Routes, jwtSecret []byte) {
+	api := app.Group("/api")
+
+	api.Post("/auth/register", r.Auth.Register)
+	api.Post("/auth/login", r.Auth.Login)
+
+	api.Get("/sse/:formId", middleware.AuthOptional(jwtSecret), middleware.StreamTokenAuth(jwtSecret, "formId"), r.Stream.Stream)
+
+	public := api.Group("", middleware.AuthOptional(jwtSecret))
+	public.Get("/forms/:id", r.Form.GetForm)
+	public.Get("/forms/:id/analytics", r.Analytics.GetAnalytics)
+	public.Post("/forms/:id/response", r.Response.SubmitResponse)
+	public.Post("/forms/:id/validate-page", r.Response.ValidatePage)
+	public.Post("/forms/:id/files/:fieldId", r.File.UploadFile)
+	public.Get("/templates", r.Template.ListTemplates)
+	public.Get("/templates/:id", r.Template.GetTemplate)
+
+	priv := api.Group("", middleware.AuthRequired(jwtSecret))
+	priv.Get("/me", r.Auth.Me)
+	priv.Get("/my/forms", r.Form.ListMyForms)
+	priv.Get("/my/forms/trash", r.Form.ListTrash)
+	priv.Post("/forms", r.Form.CreateForm)
+	priv.Put("/forms/:id", r.Form.UpdateForm)
+	priv.Delete("/forms/:id", r.Form.DeleteForm)
+	priv.Post("/forms/:id/restore", r.Form.RestoreForm)
+	priv.Post("/forms/:id/duplicate", r.Form.DuplicateForm)
+	priv.Put("/forms/:id/template", r.Template.MarkTemplate)
+	priv.Put("/forms/:id/public-results", r.Analytics.SetPublicResults)
+	priv.Get("/forms/:id/export", r.Export.ExportResponses)
+	priv.Get("/files/:id", r.File.DownloadFile)
+	priv.Post("/forms/:id/stream-token", r.Stream.IssueStreamToken)
+	priv.Get("/forms/:id/collaborators", r.Collab.ListCollaborators)
+	priv.Put("/forms/:id/collaborators", r.Collab.ShareForm)
+	priv.Delete("/forms/:id/collaborators/:userId", r.Collab.RemoveCollaborator)
+	priv.Post("/forms/:id/transfer", r.Collab.TransferOwnership)
+	priv.Post("/templates/:id/instantiate", r.Template.Instantiate)
+	priv.Get("/forms/:id/revisions", r.Revision.ListRevisions)
+	priv.Get("/forms/:id/revisions/:rev", r.Revision.GetRevision)
+	priv.Post("/forms/:id/revisions/:rev/restore", r.Revision.RestoreRevision)
+	priv.Get("/forms/:id/diff", r.Revision.DiffRevisions)
+	priv.Get("/my/workspaces", r.Workspace.ListMyWorkspaces)
+	priv.Post("/workspaces", r.Workspace.CreateWorkspace)
+	priv.Get("/workspaces/:id", r.Workspace.GetWorkspace)
+	priv.Put("/workspaces/:id", r.Workspace.RenameWorkspace)
+	priv.Delete("/workspaces/:id", r.Workspace.DeleteWorkspace)
+	priv.Get("/workspaces/:id/forms", r.Workspace.ListWorkspaceForms)
+	priv.Put("/workspaces/:id/members", r.Workspace.SetMember)
+	priv.Delete("/workspaces/:id/members/:userId", r.Workspace.RemoveMember)
+}
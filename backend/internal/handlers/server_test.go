@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/blob"
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/db"
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/ws"
+)
+
+// testServer wires the handlers to a MemoryStore and a LocalStore in a temp
+// directory, mounted with RegisterRoutes like main.go.
+type testServer struct {
+	t     *testing.T
+	app   *fiber.App
+	store *db.MemoryStore
+	blobs *blob.LocalStore
+	auth  *AuthHandler
+}
+
+// testPublicURL is the configured origin the test server links files under.
+const testPublicURL = "https://forms.example.com"
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	store := db.NewMemoryStore()
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("test-secret")
+
+	templateH, err := NewTemplateHandler(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authH := NewAuthHandler(store, secret)
+	hub := ws.NewHub()
+
+	app := fiber.New(fiber.Config{BodyLimit: MaxUploadSize + 1<<20, DisableStartupMessage: true})
+	RegisterRoutes(app, Routes{
+		Auth:      authH,
+		Form:      NewFormHandler(store, blobs),
+		Revision:  NewRevisionHandler(store),
+		Collab:    NewCollaboratorHandler(store),
+		Workspace: NewWorkspaceHandler(store),
+		Response:  NewResponseHandler(store, hub.Broadcast),
+		Analytics: NewAnalyticsHandler(store),
+		Export:    NewExportHandler(store, testPublicURL),
+		File:      NewFileHandler(store, blobs, nil),
+		Template:  templateH,
+		Stream:    NewStreamHandler(store, hub, secret),
+	}, secret)
+
+	return &testServer{t: t, app: app, store: store, blobs: blobs, auth: authH}
+}
+
+// user registers a user directly in the store and returns its id.
+func (s *testServer) user(email string) string {
+	s.t.Helper()
+	u := &models.User{ID: uuid.NewString(), Email: email, Name: email}
+	if err := s.store.CreateUser(context.Background(), u); err != nil {
+		s.t.Fatal(err)
+	}
+	return u.ID
+}
+
+// do sends a JSON request as userID ("" for anonymous) and returns the status
+// and raw body.
+func (s *testServer) do(method, path, userID string, body interface{}) (int, []byte) {
+	s.t.Helper()
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		r = bytes.NewReader(b)
+	}
+	req := httptest.NewRequest(method, path, r)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return s.send(req, userID)
+}
+
+// authorize signs req in as userID; "" leaves it anonymous.
+func (s *testServer) authorize(req *http.Request, userID string) {
+	s.t.Helper()
+	if userID == "" {
+		return
+	}
+	tok, err := s.auth.makeToken(userID)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+tok)
+}
+
+func (s *testServer) send(req *http.Request, userID string) (int, []byte) {
+	s.t.Helper()
+	s.authorize(req, userID)
+	res, err := s.app.Test(req, -1)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return res.StatusCode, b
+}
+
+// upload posts data as the multipart "file" of a file field, anonymously.
+func (s *testServer) upload(formID, fieldID, name string, data []byte) (int, []byte) {
+	s.t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	fw.Write(data)
+	mw.Close()
+	req := httptest.NewRequest("POST", "/api/forms/"+formID+"/files/"+fieldID, &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return s.send(req, "")
+}
+
+// decode unmarshals a response body, failing the test on malformed JSON.
+func decode[T any](t *testing.T, b []byte) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("decode %s: %v", b, err)
+	}
+	return v
+}
+
+// createForm creates a form owned by userID and returns it as stored.
+func (s *testServer) createForm(userID string, form fiber.Map) models.Form {
+	s.t.Helper()
+	status, body := s.do("POST", "/api/forms", userID, form)
+	if status != fiber.StatusCreated {
+		s.t.Fatalf("create form: %d %s", status, body)
+	}
+	return decode[models.Form](s.t, body)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/db"
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/middleware"
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/ws"
+)
+
+// streamTokenTTL only bounds how long a token can be used to open a stream;
+// an open stream is not cut off when its token expires.
+const streamTokenTTL = 2 * time.Minute
+
+type StreamHandler struct {
+	Store     db.Store
+	Hub       *ws.Hub
+	JWTSecret []byte
+}
+
+func NewStreamHandler(s db.Store, hub *ws.Hub, secret []byte) *StreamHandler {
+	return &StreamHandler{Store: s, Hub: hub, JWTSecret: secret}
+}
+
+// IssueStreamToken returns a short-lived token for ?token= on the SSE
+// endpoint, since EventSource cannot send an Authorization header.
+func (h *StreamHandler) IssueStreamToken(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	form, err := loadFormFor(c, h.Store, c.Params("id"), userID, models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	tok, exp, err := middleware.NewStreamToken(h.JWTSecret, userID, form.ID, streamTokenTTL)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(fiber.Map{"token": tok, "expiresAt": exp.Unix()})
+}
+
+// publicChannel is the hub channel carrying a form's public results; members
+// subscribe to the form id itself and also get verbatim answers.
+func publicChannel(formID string) string {
+	return formID + "/public"
+}
+
+// Stream pushes live analytics for a form as server-sent events. Access is
+// the same as for GET /forms/:id/analytics; it is checked before subscribing
+// and again before every update, and the stream ends once the caller lost
+// access or would now be served a different channel, e.g. after being
+// removed as a collaborator or the form moving to the trash.
+func (h *StreamHandler) Stream(c *fiber.Ctx) error {
+	// the stream outlives the handler, and with it the request buffer that
+	// Params points into
+	formID := utils.CopyString(c.Params("formId"))
+	userID, _ := c.Locals("userId").(string)
+
+	channel, err := h.channelFor(c.Context(), formID, userID)
+	if err != nil {
+		return err
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		sub := h.Hub.Subscribe(channel)
+		defer h.Hub.Unsubscribe(channel, sub)
+
+		hello, _ := json.Marshal(fiber.Map{"type": "hello", "ts": time.Now().Unix()})
+		w.WriteString("event: message\n")
+		w.WriteString("data: ")
+		w.Write(hello)
+		w.WriteString("\n\n")
+		_ = w.Flush()
+
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case msg, ok := <-sub:
+				if !ok {
+					return
+				}
+				if now, err := h.channelFor(context.Background(), formID, userID); err != nil || now != channel {
+					return
+				}
+				w.WriteString("event: message\n")
+				w.WriteString("data: ")
+				w.Write(msg)
+				w.WriteString("\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			case <-ticker.C:
+				w.WriteString(": ping\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+// channelFor returns the hub channel userID may follow for formID, or the
+// error GET /forms/:id/analytics would answer with.
+func (h *StreamHandler) channelFor(ctx context.Context, formID, userID string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 8*time.Second)
+	defer cancel()
+	form, err := h.Store.GetForm(ctx, formID)
+	if err != nil {
+		return "", fiber.NewError(fiber.StatusNotFound, "form not found")
+	}
+	member, err := canReadResults(ctx, h.Store, form, userID)
+	if err != nil {
+		return "", err
+	}
+	if !member {
+		return publicChannel(formID), nil
+	}
+	return formID, nil
+}
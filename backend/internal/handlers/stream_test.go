@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestStreamToken(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	outsider := s.user("outsider@x.io")
+	f := s.createForm(owner, fiber.Map{"title": "Survey"})
+	path := "/api/forms/" + f.ID + "/stream-token"
+
+	if status, _ := s.do("POST", path, outsider, nil); status != fiber.StatusForbidden {
+		t.Errorf("outsider: %d, want 403", status)
+	}
+	status, body := s.do("POST", path, owner, nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("issue: %d %s", status, body)
+	}
+	tok := decode[struct {
+		Token     string `json:"token"`
+		ExpiresAt int64  `json:"expiresAt"`
+	}](t, body)
+	if tok.Token == "" || tok.ExpiresAt <= time.Now().Unix() {
+		t.Fatalf("token = %+v", tok)
+	}
+
+	// a stream token only opens the stream, it is no session
+	req := httptest.NewRequest("GET", "/api/me", nil)
+	req.Header.Set("Authorization", "Bearer "+tok.Token)
+	if status, _ := s.send(req, ""); status != fiber.StatusUnauthorized {
+		t.Errorf("stream token as session: %d, want 401", status)
+	}
+}
+
+// nextEvent returns the data of the next server-sent event on r, skipping
+// comments such as pings.
+func nextEvent(r *bufio.Reader) (string, error) {
+	var data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "" && data != "":
+			return data, nil
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStreamEndsWhenAccessIsRevoked(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	viewer := s.user("viewer@x.io")
+	f := s.createForm(owner, fiber.Map{"title": "Survey", "status": "published", "fields": analyticsFields()})
+	if status, body := s.do("PUT", "/api/forms/"+f.ID+"/collaborators", owner, fiber.Map{"email": "viewer@x.io", "role": "viewer"}); status != fiber.StatusOK {
+		t.Fatalf("share: %d %s", status, body)
+	}
+	_, body := s.do("POST", "/api/forms/"+f.ID+"/stream-token", viewer, nil)
+	tok := decode[struct {
+		Token string `json:"token"`
+	}](t, body).Token
+
+	// streaming needs a real connection; app.Test waits for the whole body
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.app.Listener(ln)
+	t.Cleanup(func() { _ = s.app.ShutdownWithTimeout(time.Second) })
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get("http://" + ln.Addr().String() + "/api/sse/" + f.ID + "?token=" + tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("open stream: %d", resp.StatusCode)
+	}
+	events := bufio.NewReader(resp.Body)
+	if ev, err := nextEvent(events); err != nil || !strings.Contains(ev, `"hello"`) {
+		t.Fatalf("first event = %q, %v", ev, err)
+	}
+
+	s.submitAll(f.ID, fiber.Map{"color": "Red"})
+	if ev, err := nextEvent(events); err != nil || !strings.Contains(ev, `"response:new"`) {
+		t.Fatalf("update = %q, %v", ev, err)
+	}
+
+	if status, body := s.do("DELETE", "/api/forms/"+f.ID+"/collaborators/"+viewer, owner, nil); status != fiber.StatusNoContent {
+		t.Fatalf("unshare: %d %s", status, body)
+	}
+	s.submitAll(f.ID, fiber.Map{"color": "Blue"})
+	if ev, err := nextEvent(events); err != io.EOF {
+		t.Errorf("after unsharing got %q, %v; want the stream to end", ev, err)
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/db"
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+type TemplateHandler struct {
+	Store  db.Store
+	system map[string]*models.Form
+}
+
+// NewTemplateHandler validates the built-in templates so a broken one stops
+// the server at startup instead of failing when someone instantiates it.
+func NewTemplateHandler(s db.Store) (*TemplateHandler, error) {
+	h := &TemplateHandler{Store: s, system: make(map[string]*models.Form, len(builtinTemplates))}
+	for i := range builtinTemplates {
+		t := builtinTemplates[i]
+		t.Fields = append([]models.FormField(nil), t.Fields...)
+		for j := range t.Fields {
+			if err := validateField(&t.Fields[j]); err != nil {
+				return nil, fmt.Errorf("template %s fields[%d]: %v", t.ID, j, err)
+			}
+		}
+		if err := validatePages(t.Fields); err != nil {
+			return nil, fmt.Errorf("template %s %v", t.ID, err)
+		}
+		t.Status = "published"
+		t.OwnerID = models.TemplateSystem
+		t.Template = models.TemplateSystem
+		t.Revision = 1
+		h.system[t.ID] = &t
+	}
+	return h, nil
+}
+
+// ListTemplates returns system templates, every public template and, for a
+// signed-in caller, the private ones on forms they can access, including
+// their workspaces' forms.
+func (h *TemplateHandler) ListTemplates(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+
+	out := make([]models.Form, 0, len(h.system))
+	for _, t := range h.system {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Title < out[j].Title })
+
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	public, err := h.Store.ListForms(ctx, db.FormQuery{Template: models.TemplatePublic})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	out = append(out, public...)
+
+	if userID != "" {
+		wsIDs, err := workspaceIDs(ctx, h.Store, userID, models.RoleViewer)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		mine, err := h.Store.ListForms(ctx, db.FormQuery{MemberID: userID, WorkspaceIDs: wsIDs, Template: models.TemplatePrivate})
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		out = append(out, mine...)
+	}
+	// a template shares its fields, not who the form is shared with
+	for i := range out {
+		out[i].Collaborators = nil
+	}
+	return c.JSON(out)
+}
+
+func (h *TemplateHandler) GetTemplate(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	t, err := h.loadTemplate(c, c.Params("id"), userID)
+	if err != nil {
+		return err
+	}
+	out := *t
+	out.Collaborators = nil
+	return c.JSON(out)
+}
+
+type instantiateReq struct {
+	Title string `json:"title"`
+}
+
+// Instantiate creates a new draft form for the caller from a template.
+func (h *TemplateHandler) Instantiate(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	t, err := h.loadTemplate(c, c.Params("id"), userID)
+	if err != nil {
+		return err
+	}
+
+	var in instantiateReq
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&in); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+
+	fields, _ := cloneFields(t.Fields)
+	form := models.Form{
+		ID:       uuid.NewString(),
+		Title:    strings.TrimSpace(in.Title),
+		Fields:   fields,
+		Status:   "draft",
+		OwnerID:  userID,
+		Revision: 1,
+	}
+	if form.Title == "" {
+		form.Title = t.Title
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+	if err := h.Store.CreateForm(ctx, &form); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if err := recordRevision(ctx, h.Store, &form, userID); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.Status(fiber.StatusCreated).JSON(form)
+}
+
+type markTemplateReq struct {
+	Visibility string `json:"visibility"`
+}
+
+// MarkTemplate lets an owner publish a form as a private or public template,
+// or turn it back into an ordinary form with an empty visibility.
+func (h *TemplateHandler) MarkTemplate(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	form, err := loadFormFor(c, h.Store, c.Params("id"), userID, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	var in markTemplateReq
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	switch in.Visibility {
+	case "", models.TemplatePrivate, models.TemplatePublic:
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "visibility must be private, public or empty")
+	}
+
+	if form.Template != in.Visibility {
+		form.Template = in.Visibility
+		if err := saveFormMeta(c, h.Store, form); err != nil {
+			return err
+		}
+	}
+	return c.JSON(form)
+}
+
+func (h *TemplateHandler) loadTemplate(c *fiber.Ctx, id, userID string) (*models.Form, error) {
+	if t, ok := h.system[id]; ok {
+		return t, nil
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
+	defer cancel()
+
+	form, err := h.Store.GetForm(ctx, id)
+	if err != nil || form.DeletedAt != 0 {
+		return nil, fiber.NewError(fiber.StatusNotFound, "template not found")
+	}
+	switch form.Template {
+	case models.TemplatePublic:
+	case models.TemplatePrivate:
+		if roleOf(ctx, h.Store, form, userID) == "" {
+			return nil, fiber.NewError(fiber.StatusNotFound, "template not found")
+		}
+	default:
+		return nil, fiber.NewError(fiber.StatusNotFound, "template not found")
+	}
+	return form, nil
+}
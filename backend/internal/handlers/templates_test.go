@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func TestTemplates(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.user("owner@x.io")
+	other := s.user("other@x.io")
+
+	_, body := s.do("GET", "/api/templates", "", nil)
+	system := len(decode[[]models.Form](t, body))
+	if system == 0 {
+		t.Fatal("no system templates")
+	}
+
+	f := s.createForm(owner, fiber.Map{"title": "Feedback", "fields": []models.FormField{
+		{ID: "q", Type: models.FieldText, Label: "Thoughts?"},
+	}})
+	if status, _ := s.do("GET", "/api/templates/"+f.ID, owner, nil); status != fiber.StatusNotFound {
+		t.Errorf("ordinary form as template: %d, want 404", status)
+	}
+	if status, _ := s.do("PUT", "/api/forms/"+f.ID+"/template", owner, fiber.Map{"visibility": "world"}); status != fiber.StatusBadRequest {
+		t.Errorf("unknown visibility: %d, want 400", status)
+	}
+
+	if status, _ := s.do("PUT", "/api/forms/"+f.ID+"/template", owner, fiber.Map{"visibility": models.TemplatePrivate}); status != fiber.StatusOK {
+		t.Fatalf("mark private: %d", status)
+	}
+	if status, _ := s.do("GET", "/api/templates/"+f.ID, other, nil); status != fiber.StatusNotFound {
+		t.Errorf("private template for outsider: %d, want 404", status)
+	}
+	_, body = s.do("GET", "/api/templates", owner, nil)
+	if n := len(decode[[]models.Form](t, body)); n != system+1 {
+		t.Errorf("owner sees %d templates, want %d", n, system+1)
+	}
+
+	s.do("PUT", "/api/forms/"+f.ID+"/template", owner, fiber.Map{"visibility": models.TemplatePublic})
+	_, body = s.do("GET", "/api/templates", "", nil)
+	if n := len(decode[[]models.Form](t, body)); n != system+1 {
+		t.Errorf("anonymous sees %d templates, want %d", n, system+1)
+	}
+
+	status, body := s.do("POST", "/api/templates/"+f.ID+"/instantiate", other, fiber.Map{"title": "My copy"})
+	if status != fiber.StatusCreated {
+		t.Fatalf("instantiate: %d %s", status, body)
+	}
+	cp := decode[models.Form](t, body)
+	if cp.ID == f.ID || cp.OwnerID != other || cp.Title != "My copy" || cp.Status != "draft" || cp.Template != "" || len(cp.Fields) != 1 {
+		t.Errorf("instance = %+v", cp)
+	}
+
+	status, body = s.do("POST", "/api/templates/system-nps/instantiate", other, nil)
+	if status != fiber.StatusCreated {
+		t.Fatalf("instantiate system template: %d %s", status, body)
+	}
+	if cp := decode[models.Form](t, body); cp.Title == "" || len(cp.Fields) == 0 {
+		t.Errorf("system instance = %+v", cp)
+	}
+	if status, _ := s.do("POST", "/api/templates/system-nps/instantiate", "", nil); status != fiber.StatusUnauthorized {
+		t.Errorf("anonymous instantiate: %d, want 401", status)
+	}
+}
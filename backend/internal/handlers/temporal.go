@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+// temporalInputs lists the layouts accepted for date, time and datetime
+// answers; the first one is canonical and is what gets stored, so stored
+// values of one field sort chronologically as plain strings.
+var temporalInputs = map[models.FieldType][]string{
+	models.FieldDate:     {"2006-01-02"},
+	models.FieldTime:     {"15:04", "15:04:05"},
+	models.FieldDateTime: {time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04"},
+}
+
+var temporalHints = map[models.FieldType]string{
+	models.FieldDate:     "date (YYYY-MM-DD)",
+	models.FieldTime:     "time (HH:MM)",
+	models.FieldDateTime: "date-time (RFC 3339 or YYYY-MM-DDTHH:MM)",
+}
+
+func isTemporal(t models.FieldType) bool {
+	_, ok := temporalInputs[t]
+	return ok
+}
+
+// parseTemporal reads s as a value of a date, time or datetime field.
+// Date-times without a zone, as sent by datetime-local inputs, are UTC.
+func parseTemporal(t models.FieldType, s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range temporalInputs[t] {
+		if v, err := time.Parse(layout, s); err == nil {
+			return v.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("must be a %s", temporalHints[t])
+}
+
+func formatTemporal(t models.FieldType, v time.Time) string {
+	if t == models.FieldDateTime {
+		return v.UTC().Format(time.RFC3339)
+	}
+	return v.Format(temporalInputs[t][0])
+}
+
+// validateTemporalBounds normalizes Earliest/Latest to the canonical layout.
+func validateTemporalBounds(f *models.FormField) error {
+	var lo, hi time.Time
+	var err error
+	if f.Earliest != "" {
+		if lo, err = parseTemporal(f.Type, f.Earliest); err != nil {
+			return fmt.Errorf("earliest %v", err)
+		}
+		f.Earliest = formatTemporal(f.Type, lo)
+	}
+	if f.Latest != "" {
+		if hi, err = parseTemporal(f.Type, f.Latest); err != nil {
+			return fmt.Errorf("latest %v", err)
+		}
+		f.Latest = formatTemporal(f.Type, hi)
+	}
+	if f.Earliest != "" && f.Latest != "" && hi.Before(lo) {
+		return fmt.Errorf("earliest must not be after latest")
+	}
+	return nil
+}
+
+// checkTemporalAnswer validates one answer against the field's bounds and
+// returns it in canonical form.
+func checkTemporalAnswer(f *models.FormField, v interface{}) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("field '%s' must be a %s", f.ID, temporalHints[f.Type])
+	}
+	t, err := parseTemporal(f.Type, s)
+	if err != nil {
+		return "", fmt.Errorf("field '%s' %v", f.ID, err)
+	}
+	if f.Earliest != "" {
+		if lo, err := parseTemporal(f.Type, f.Earliest); err == nil && t.Before(lo) {
+			return "", fmt.Errorf("field '%s' must not be before %s", f.ID, f.Earliest)
+		}
+	}
+	if f.Latest != "" {
+		if hi, err := parseTemporal(f.Type, f.Latest); err == nil && t.After(hi) {
+			return "", fmt.Errorf("field '%s' must not be after %s", f.ID, f.Latest)
+		}
+	}
+	return formatTemporal(f.Type, t), nil
+}
+
+// temporalOrdinal maps a date, time or datetime string onto a number so that
+// ShowIf gt/lt work on such answers. Times of day count seconds since
+// midnight, dates and date-times seconds since the epoch.
+func temporalOrdinal(v interface{}) (float64, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, false
+	}
+	for _, t := range []models.FieldType{models.FieldDateTime, models.FieldDate} {
+		if tv, err := parseTemporal(t, s); err == nil {
+			return float64(tv.Unix()), true
+		}
+	}
+	if tv, err := parseTemporal(models.FieldTime, s); err == nil {
+		return float64(tv.Hour()*3600 + tv.Minute()*60 + tv.Second()), true
+	}
+	return 0, false
+}
+
+// temporalAnalytics buckets the answers of a date or datetime field by day,
+// ISO week and month, and those of a time field by hour. It returns the
+// summary and the number of responses that answered.
+func temporalAnalytics(f *models.FormField, rows []models.Response) (fiber.Map, int) {
+	var values []time.Time
+	for _, r := range rows {
+		s, ok := r.Answers[f.ID].(string)
+		if !ok || s == "" {
+			continue
+		}
+		if t, err := parseTemporal(f.Type, s); err == nil {
+			values = append(values, t)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Before(values[j]) })
+
+	out := fiber.Map{"type": f.Type}
+	if len(values) > 0 {
+		out["earliest"] = formatTemporal(f.Type, values[0])
+		out["latest"] = formatTemporal(f.Type, values[len(values)-1])
+	}
+
+	if f.Type == models.FieldTime {
+		byHour := map[string]int{}
+		for _, t := range values {
+			byHour[t.Format("15")]++
+		}
+		out["byHour"] = byHour
+		return out, len(values)
+	}
+
+	byDay, byWeek, byMonth := map[string]int{}, map[string]int{}, map[string]int{}
+	for _, t := range values {
+		byDay[t.Format("2006-01-02")]++
+		y, w := t.ISOWeek()
+		byWeek[fmt.Sprintf("%d-W%02d", y, w)]++
+		byMonth[t.Format("2006-01")]++
+	}
+	out["byDay"] = byDay
+	out["byWeek"] = byWeek
+	out["byMonth"] = byMonth
+	return out, len(values)
+}
+
+// renderTemporalPDF formats a stored value for reading; exports that feed
+// other tools (CSV) keep the canonical value.
+func renderTemporalPDF(t models.FieldType, s string) string {
+	v, err := parseTemporal(t, s)
+	if err != nil {
+		return s
+	}
+	switch t {
+	case models.FieldDate:
+		return v.Format("Jan 2, 2006")
+	case models.FieldDateTime:
+		return v.Format("Jan 2, 2006 15:04 MST")
+	default:
+		return v.Format("15:04")
+	}
+}
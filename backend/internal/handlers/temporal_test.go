@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func TestCheckTemporalAnswer(t *testing.T) {
+	tests := []struct {
+		typ              models.FieldType
+		earliest, latest string
+		in               interface{}
+		want             string
+	}{
+		{models.FieldDate, "", "", "2024-02-29", "2024-02-29"},
+		{models.FieldDate, "", "", "2023-02-29", ""},
+		{models.FieldDate, "", "", "29/02/2024", ""},
+		{models.FieldDate, "", "", 20240229.0, ""},
+		{models.FieldDate, "2024-01-01", "2024-12-31", "2023-12-31", ""},
+		{models.FieldDate, "2024-01-01", "2024-12-31", "2025-01-01", ""},
+		{models.FieldDate, "2024-01-01", "2024-12-31", "2024-12-31", "2024-12-31"},
+		{models.FieldTime, "", "", "09:30:15", "09:30"},
+		{models.FieldTime, "09:00", "17:00", "08:59", ""},
+		{models.FieldDateTime, "", "", "2024-03-01T10:00", "2024-03-01T10:00:00Z"},
+		{models.FieldDateTime, "", "", "2024-03-01T10:00:00+02:00", "2024-03-01T08:00:00Z"},
+		{models.FieldDateTime, "2024-03-01T09:00:00Z", "", "2024-03-01T10:00:00+02:00", ""},
+	}
+	for _, tt := range tests {
+		f := &models.FormField{ID: "d", Type: tt.typ, Earliest: tt.earliest, Latest: tt.latest}
+		got, err := checkTemporalAnswer(f, tt.in)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s %v: accepted as %q", tt.typ, tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s %v = %q, %v; want %q", tt.typ, tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestValidateTemporalBounds(t *testing.T) {
+	f := &models.FormField{Type: models.FieldTime, Earliest: "09:00:00", Latest: "17:30"}
+	if err := validateTemporalBounds(f); err != nil || f.Earliest != "09:00" {
+		t.Errorf("earliest = %q, %v; want normalized to 09:00", f.Earliest, err)
+	}
+	f = &models.FormField{Type: models.FieldDate, Earliest: "2024-02-01", Latest: "2024-01-01"}
+	if err := validateTemporalBounds(f); err == nil {
+		t.Error("accepted earliest after latest")
+	}
+	f = &models.FormField{Type: models.FieldDate, Earliest: "tomorrow"}
+	if err := validateTemporalBounds(f); err == nil {
+		t.Error("accepted a malformed bound")
+	}
+}
+
+func TestTemporalAnalytics(t *testing.T) {
+	f := &models.FormField{ID: "d", Type: models.FieldDate}
+	rows := []models.Response{
+		{Answers: map[string]interface{}{"d": "2024-01-31"}},
+		{Answers: map[string]interface{}{"d": "2024-01-01"}},
+		{Answers: map[string]interface{}{"d": "2024-02-01"}},
+		{Answers: map[string]interface{}{"d": "garbage"}},
+		{Answers: map[string]interface{}{}},
+	}
+	out, n := temporalAnalytics(f, rows)
+	if n != 3 {
+		t.Errorf("answered = %d, want 3", n)
+	}
+	if out["earliest"] != "2024-01-01" || out["latest"] != "2024-02-01" {
+		t.Errorf("range = %v..%v", out["earliest"], out["latest"])
+	}
+	if got := out["byMonth"].(map[string]int); got["2024-01"] != 2 || got["2024-02"] != 1 {
+		t.Errorf("byMonth = %v", got)
+	}
+	// 2024-01-01 is a Monday, so it is the first day of ISO week 1
+	if got := out["byWeek"].(map[string]int); got["2024-W01"] != 1 || got["2024-W05"] != 2 {
+		t.Errorf("byWeek = %v", got)
+	}
+
+	f = &models.FormField{ID: "t", Type: models.FieldTime}
+	rows = []models.Response{
+		{Answers: map[string]interface{}{"t": "09:15"}},
+		{Answers: map[string]interface{}{"t": "09:45"}},
+		{Answers: map[string]interface{}{"t": "17:00"}},
+	}
+	out, n = temporalAnalytics(f, rows)
+	if got := out["byHour"].(map[string]int); n != 3 || got["09"] != 2 || got["17"] != 1 {
+		t.Errorf("byHour = %v (n=%d)", got, n)
+	}
+	if _, ok := out["byDay"]; ok {
+		t.Error("time field has byDay")
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func validateTextField(f *models.FormField) error {
+	if f.MinLength < 0 || f.MaxLength < 0 {
+		return fmt.Errorf("minLength and maxLength must not be negative")
+	}
+	if f.MaxLength > 0 && f.MinLength > f.MaxLength {
+		return fmt.Errorf("minLength must not be greater than maxLength")
+	}
+	if f.Pattern == "" {
+		if f.PatternMessage != "" {
+			return fmt.Errorf("patternMessage requires a pattern")
+		}
+		return nil
+	}
+	// compile the pattern as written so errors point at the user's text
+	if _, err := regexp.Compile(f.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern: %v", err)
+	}
+	return nil
+}
+
+// compilePattern anchors p so that it has to match the whole answer, the way
+// the HTML pattern attribute does.
+func compilePattern(p string) (*regexp.Regexp, error) {
+	return regexp.Compile(`^(?:` + p + `)$`)
+}
+
+// checkTextAnswer applies the length, pattern and single-line constraints of
+// a text field. Lengths count characters, not bytes.
+func checkTextAnswer(f *models.FormField, s string) error {
+	if s == "" {
+		return nil
+	}
+	if singleLine(f) && strings.ContainsAny(s, "\r\n") {
+		return fmt.Errorf("field '%s' must be a single line", f.ID)
+	}
+	n := utf8.RuneCountInString(s)
+	if f.MinLength > 0 && n < f.MinLength {
+		return fmt.Errorf("field '%s' must be at least %d characters", f.ID, f.MinLength)
+	}
+	if f.MaxLength > 0 && n > f.MaxLength {
+		return fmt.Errorf("field '%s' must be at most %d characters", f.ID, f.MaxLength)
+	}
+	if f.Pattern != "" {
+		re, err := compilePattern(f.Pattern)
+		if err != nil {
+			return fmt.Errorf("field '%s' has an invalid pattern", f.ID)
+		}
+		if !re.MatchString(s) {
+			if f.PatternMessage != "" {
+				return fmt.Errorf("field '%s': %s", f.ID, f.PatternMessage)
+			}
+			return fmt.Errorf("field '%s' does not match the expected format", f.ID)
+		}
+	}
+	return nil
+}
+
+// singleLine reports whether f was explicitly made a single-line field.
+func singleLine(f *models.FormField) bool {
+	return f.Multiline != nil && !*f.Multiline
+}
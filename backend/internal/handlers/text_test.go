@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func TestValidateTextField(t *testing.T) {
+	tests := []struct {
+		name string
+		f    models.FormField
+		ok   bool
+	}{
+		{"plain", models.FormField{}, true},
+		{"negative", models.FormField{MinLength: -1}, false},
+		{"min > max", models.FormField{MinLength: 5, MaxLength: 2}, false},
+		{"min without max", models.FormField{MinLength: 5}, true},
+		{"bad pattern", models.FormField{Pattern: "("}, false},
+		{"message without pattern", models.FormField{PatternMessage: "digits"}, false},
+		{"pattern", models.FormField{Pattern: `\d+`, PatternMessage: "digits"}, true},
+	}
+	for _, tt := range tests {
+		tt.f.Type = models.FieldText
+		if err := validateTextField(&tt.f); (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func boolPtr(v bool) *bool { return &v }
+
+func TestCheckTextAnswer(t *testing.T) {
+	tests := []struct {
+		name string
+		f    models.FormField
+		in   string
+		ok   bool
+	}{
+		{"empty skips checks", models.FormField{MinLength: 3}, "", true},
+		{"newline without the flag", models.FormField{}, "a\nb", true},
+		{"newline in single line", models.FormField{Multiline: boolPtr(false)}, "a\nb", false},
+		{"newline in multiline", models.FormField{Multiline: boolPtr(true)}, "a\nb", true},
+		{"too short", models.FormField{MinLength: 3}, "ab", false},
+		{"runes not bytes", models.FormField{MaxLength: 3}, "äöü", true},
+		{"too long", models.FormField{MaxLength: 3}, "abcd", false},
+		{"pattern is anchored", models.FormField{Pattern: `\d+`}, "12a", false},
+		{"pattern", models.FormField{Pattern: `\d+`}, "123", true},
+		{"alternation is anchored", models.FormField{Pattern: `a|b`}, "ab", false},
+	}
+	for _, tt := range tests {
+		tt.f.ID = "t"
+		if err := checkTextAnswer(&tt.f, tt.in); (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestCheckTextAnswerPatternMessage(t *testing.T) {
+	f := &models.FormField{ID: "zip", Pattern: `\d{5}`, PatternMessage: "enter five digits"}
+	err := checkTextAnswer(f, "abc")
+	if err == nil || err.Error() != "field 'zip': enter five digits" {
+		t.Errorf("err = %v", err)
+	}
+}
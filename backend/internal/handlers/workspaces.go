@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/db"
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+type WorkspaceHandler struct {
+	Store db.Store
+}
+
+func NewWorkspaceHandler(s db.Store) *WorkspaceHandler { return &WorkspaceHandler{Store: s} }
+
+type workspaceReq struct {
+	Name string `json:"name"`
+}
+
+type workspaceView struct {
+	ID      string             `json:"id"`
+	Name    string             `json:"name"`
+	Created int64              `json:"created"`
+	Members []collaboratorView `json:"members"`
+}
+
+// CreateWorkspace starts a workspace with the caller as its only owner.
+func (h *WorkspaceHandler) CreateWorkspace(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+
+	var in workspaceReq
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if in.Name = strings.TrimSpace(in.Name); in.Name == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "name is required")
+	}
+
+	w := models.Workspace{
+		ID:      uuid.NewString(),
+		Name:    in.Name,
+		Members: []models.Collaborator{{UserID: userID, Role: models.RoleOwner}},
+		Created: time.Now().Unix(),
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+	if err := h.Store.CreateWorkspace(ctx, &w); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.Status(fiber.StatusCreated).JSON(w)
+}
+
+func (h *WorkspaceHandler) ListMyWorkspaces(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	out, err := h.Store.ListWorkspaces(ctx, userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if out == nil {
+		out = []models.Workspace{}
+	}
+	return c.JSON(out)
+}
+
+// GetWorkspace returns the workspace with its members' names and emails.
+func (h *WorkspaceHandler) GetWorkspace(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	w, err := loadWorkspaceFor(c, h.Store, c.Params("id"), userID, models.RoleViewer)
+	if err != nil {
+		return err
+	}
+	return c.JSON(h.view(c, w))
+}
+
+func (h *WorkspaceHandler) RenameWorkspace(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	w, err := loadWorkspaceFor(c, h.Store, c.Params("id"), userID, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	var in workspaceReq
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if in.Name = strings.TrimSpace(in.Name); in.Name == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "name is required")
+	}
+	w.Name = in.Name
+	if err := h.save(c, w); err != nil {
+		return err
+	}
+	return c.JSON(w)
+}
+
+// DeleteWorkspace removes an empty workspace. Forms, trashed ones included,
+// must be transferred or purged first so that none is left without an owner.
+func (h *WorkspaceHandler) DeleteWorkspace(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	w, err := loadWorkspaceFor(c, h.Store, c.Params("id"), userID, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	for _, trashed := range []bool{false, true} {
+		forms, err := h.Store.ListForms(ctx, db.FormQuery{WorkspaceID: w.ID, Trashed: trashed})
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		if len(forms) > 0 {
+			return fiber.NewError(fiber.StatusConflict, "workspace still owns forms; transfer or delete them first")
+		}
+	}
+	if err := h.Store.DeleteWorkspace(ctx, w.ID); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// SetMember adds a registered user to the workspace or changes their role.
+// Owner only; a workspace may have several owners.
+func (h *WorkspaceHandler) SetMember(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	w, err := loadWorkspaceFor(c, h.Store, c.Params("id"), userID, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	var in shareReq
+	if err := c.BodyParser(&in); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if _, ok := roleRank[in.Role]; !ok {
+		return fiber.NewError(fiber.StatusBadRequest, "role must be owner, editor or viewer")
+	}
+	u, err := findUser(c, h.Store, in.Email)
+	if err != nil {
+		return err
+	}
+
+	w.Members = withoutCollaborator(w.Members, u.ID)
+	w.Members = append(w.Members, models.Collaborator{UserID: u.ID, Role: in.Role})
+	if !hasOwner(w.Members) {
+		return fiber.NewError(fiber.StatusBadRequest, "a workspace needs at least one owner")
+	}
+	if err := h.save(c, w); err != nil {
+		return err
+	}
+	return c.JSON(h.view(c, w))
+}
+
+// RemoveMember revokes workspace membership. Owners may remove anyone;
+// members may only leave themselves. The last owner cannot leave.
+func (h *WorkspaceHandler) RemoveMember(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	target := c.Params("userId")
+	min := models.RoleOwner
+	if target == userID {
+		min = models.RoleViewer
+	}
+	w, err := loadWorkspaceFor(c, h.Store, c.Params("id"), userID, min)
+	if err != nil {
+		return err
+	}
+
+	n := len(w.Members)
+	w.Members = withoutCollaborator(w.Members, target)
+	if len(w.Members) == n {
+		return fiber.NewError(fiber.StatusNotFound, "member not found")
+	}
+	if !hasOwner(w.Members) {
+		return fiber.NewError(fiber.StatusBadRequest, "a workspace needs at least one owner")
+	}
+	if err := h.save(c, w); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListWorkspaceForms returns the live forms owned by the workspace.
+func (h *WorkspaceHandler) ListWorkspaceForms(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userId").(string)
+	if userID == "" {
+		return fiber.ErrUnauthorized
+	}
+	w, err := loadWorkspaceFor(c, h.Store, c.Params("id"), userID, models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	out, err := h.Store.ListForms(ctx, db.FormQuery{WorkspaceID: w.ID})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(out)
+}
+
+// save stores w on top of the revision it was loaded at, so that of two
+// concurrent membership edits the later one fails instead of undoing the other.
+func (h *WorkspaceHandler) save(c *fiber.Ctx, w *models.Workspace) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	prev := w.Revision
+	w.Revision++
+	err := h.Store.UpdateWorkspace(ctx, w, prev)
+	if errors.Is(err, db.ErrConflict) {
+		return fiber.NewError(fiber.StatusConflict, "workspace was modified concurrently; retry")
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return nil
+}
+
+func (h *WorkspaceHandler) view(c *fiber.Ctx, w *models.Workspace) workspaceView {
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	v := workspaceView{ID: w.ID, Name: w.Name, Created: w.Created, Members: []collaboratorView{}}
+	for _, m := range w.Members {
+		v.Members = append(v.Members, describe(ctx, h.Store, m.UserID, m.Role))
+	}
+	return v
+}
+
+func hasOwner(members []models.Collaborator) bool {
+	for _, m := range members {
+		if m.Role == models.RoleOwner {
+			return true
+		}
+	}
+	return false
+}
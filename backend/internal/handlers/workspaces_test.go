@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/YiTing623/Custom-Form-Builder-with-Live-Analytics/internal/models"
+)
+
+func TestWorkspaces(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.user("alice@x.io")
+	bob := s.user("bob@x.io")
+	carol := s.user("carol@x.io")
+
+	status, body := s.do("POST", "/api/workspaces", alice, fiber.Map{"name": " Team "})
+	if status != fiber.StatusCreated {
+		t.Fatalf("create: %d %s", status, body)
+	}
+	w := decode[models.Workspace](t, body)
+	if w.Name != "Team" {
+		t.Errorf("name = %q, want trimmed", w.Name)
+	}
+	path := "/api/workspaces/" + w.ID
+
+	if status, _ := s.do("PUT", path+"/members", alice, fiber.Map{"email": "bob@x.io", "role": "editor"}); status != fiber.StatusOK {
+		t.Fatalf("add bob: %d", status)
+	}
+	if status, _ := s.do("PUT", path+"/members", alice, fiber.Map{"email": "carol@x.io", "role": "admin"}); status != fiber.StatusBadRequest {
+		t.Errorf("unknown role: %d, want 400", status)
+	}
+
+	// an editor creates a form that the workspace owns
+	f := s.createForm(bob, fiber.Map{"title": "Team form", "workspaceId": w.ID})
+	if f.OwnerID != "" || f.WorkspaceID != w.ID {
+		t.Errorf("form owner %q workspace %q", f.OwnerID, f.WorkspaceID)
+	}
+	if status, _ := s.do("POST", "/api/forms", carol, fiber.Map{"title": "Sneaky", "workspaceId": w.ID}); status != fiber.StatusNotFound {
+		t.Errorf("outsider creates in workspace: %d, want 404", status)
+	}
+
+	_, body = s.do("GET", "/api/my/forms", alice, nil)
+	if forms := decode[[]models.Form](t, body); len(forms) != 1 || forms[0].ID != f.ID {
+		t.Errorf("alice's forms = %v", forms)
+	}
+	if status, _ := s.do("GET", "/api/forms/"+f.ID, carol, nil); status != fiber.StatusForbidden {
+		t.Errorf("outsider reads workspace draft: %d, want 403", status)
+	}
+	if status, _ := s.do("DELETE", "/api/forms/"+f.ID, bob, nil); status != fiber.StatusForbidden {
+		t.Errorf("editor deletes workspace form: %d, want 403", status)
+	}
+
+	tests := []struct {
+		name, method, path, user string
+		body                     interface{}
+		want                     int
+	}{
+		{"outsider reads", "GET", path, carol, nil, fiber.StatusNotFound},
+		{"editor renames", "PUT", path, bob, fiber.Map{"name": "Mine"}, fiber.StatusForbidden},
+		{"owner renames", "PUT", path, alice, fiber.Map{"name": "Renamed"}, fiber.StatusOK},
+		{"delete with forms", "DELETE", path, alice, nil, fiber.StatusConflict},
+		{"last owner leaves", "DELETE", path + "/members/" + alice, alice, nil, fiber.StatusBadRequest},
+		{"editor leaves", "DELETE", path + "/members/" + bob, bob, nil, fiber.StatusNoContent},
+		{"editor after leaving", "GET", "/api/forms/" + f.ID, bob, nil, fiber.StatusForbidden},
+		{"purge form", "DELETE", "/api/forms/" + f.ID + "?permanent=true", alice, nil, fiber.StatusOK},
+		{"delete empty", "DELETE", path, alice, nil, fiber.StatusNoContent},
+	}
+	for _, tt := range tests {
+		if status, body := s.do(tt.method, tt.path, tt.user, tt.body); status != tt.want {
+			t.Errorf("%s: %d %s, want %d", tt.name, status, body, tt.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
@@ -36,6 +37,46 @@ func AuthRequired(secret []byte) fiber.Handler {
 	}
 }
 
+// StreamTokenAuth accepts a stream token in ?token= for the form named by
+// the route parameter param. EventSource cannot send headers, so this is how
+// browsers authenticate SSE. A caller already identified by AuthOptional
+// keeps that identity.
+func StreamTokenAuth(secret []byte, param string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Query("token")
+		if token == "" || c.Locals("userId") != nil {
+			return c.Next()
+		}
+		claims := &jwt.RegisteredClaims{}
+		_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+			return secret, nil
+		}, jwt.WithAudience(streamAudience(c.Params(param))), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+		if err != nil || claims.Subject == "" {
+			return fiber.NewError(fiber.StatusUnauthorized, "invalid stream token")
+		}
+		c.Locals("userId", claims.Subject)
+		return c.Next()
+	}
+}
+
+// NewStreamToken signs a short-lived token that only opens the live stream
+// of one form; it is not accepted as a session token anywhere else.
+func NewStreamToken(secret []byte, userID, formID string, ttl time.Duration) (string, time.Time, error) {
+	exp := time.Now().Add(ttl)
+	claims := jwt.RegisteredClaims{
+		Subject:   userID,
+		Audience:  jwt.ClaimStrings{streamAudience(formID)},
+		ExpiresAt: jwt.NewNumericDate(exp),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	}
+	tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	return tok, exp, err
+}
+
+func streamAudience(formID string) string {
+	return "stream:" + formID
+}
+
 func extractToken(c *fiber.Ctx) string {
 	h := c.Get("Authorization")
 	if strings.HasPrefix(h, "Bearer ") {
@@ -54,7 +95,9 @@ func parseJWT(token string, secret []byte) (*jwt.RegisteredClaims, error) {
 	if err != nil {
 		return nil, err
 	}
-	if claims, ok := tok.Claims.(*jwt.RegisteredClaims); ok && tok.Valid {
+	// session tokens carry no audience; anything scoped (stream tokens) is
+	// only good for the endpoint it was issued for
+	if claims, ok := tok.Claims.(*jwt.RegisteredClaims); ok && tok.Valid && len(claims.Audience) == 0 {
 		return claims, nil
 	}
 	return nil, fiber.ErrUnauthorized
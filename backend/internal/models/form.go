@@ -3,29 +3,60 @@ package models
 type FieldType string
 
 const (
-	FieldText	 FieldType = "text"
+	FieldText     FieldType = "text"
 	FieldMultiple FieldType = "multiple"
 	FieldCheckbox FieldType = "checkbox"
 	FieldRating   FieldType = "rating"
+	FieldDate     FieldType = "date"
+	FieldTime     FieldType = "time"
+	FieldDateTime FieldType = "datetime"
+	FieldNumber   FieldType = "number"
+	FieldEmail    FieldType = "email"
+	FieldURL      FieldType = "url"
+	FieldPhone    FieldType = "phone"
+	FieldMatrix   FieldType = "matrix"
+	FieldRanking  FieldType = "ranking"
+	FieldNPS      FieldType = "nps"
+	FieldFile     FieldType = "file"
+	FieldDropdown FieldType = "dropdown"
+	FieldYesNo    FieldType = "yesno"
+	FieldLikert   FieldType = "likert"
+	FieldSlider   FieldType = "slider"
+
+	// Display-only blocks: they take no answer.
+	FieldHeading   FieldType = "heading"
+	FieldParagraph FieldType = "paragraph"
+	FieldImage     FieldType = "image"
+	FieldDivider   FieldType = "divider"
+	FieldPageBreak FieldType = "pagebreak"
 )
 
 type ConditionOperator string
 
 const (
-	OpEq ConditionOperator = "eq"
-	OpNe ConditionOperator = "ne"
+	OpEq       ConditionOperator = "eq"
+	OpNe       ConditionOperator = "ne"
 	OpIncludes ConditionOperator = "includes"
-	OpGt ConditionOperator = "gt"
-	OpLt ConditionOperator = "lt"
-	OpGte ConditionOperator = "gte"
-	OpLte ConditionOperator = "lte"
-
+	OpGt       ConditionOperator = "gt"
+	OpLt       ConditionOperator = "lt"
+	OpGte      ConditionOperator = "gte"
+	OpLte      ConditionOperator = "lte"
 )
 
 type ShowIf struct {
-	FieldID string `bson:"fieldId" json:"fieldId"`
+	FieldID  string            `bson:"fieldId" json:"fieldId"`
 	Operator ConditionOperator `bson:"op" json:"op"`
-	Value interface{} `bson:"value" json:"value"`
+	Value    interface{}       `bson:"value" json:"value"`
+}
+
+// JumpToEnd as a jump target skips every remaining page.
+const JumpToEnd = "end"
+
+// JumpRule sends respondents leaving a page to a later page when If holds.
+// To is the id of the page break that opens the target page, or JumpToEnd.
+type JumpRule struct {
+	If ShowIf `bson:"if" json:"if"`
+	To string `bson:"to" json:"to"`
 }
 
 type FormField struct {
@@ -34,26 +65,159 @@ type FormField struct {
 	Label    string    `bson:"label" json:"label"`
 	Required bool      `bson:"required" json:"required"`
 
+	// Options are the choices of multiple, checkbox, dropdown and ranking
+	// fields, and the labels of a likert scale from its negative end up.
 	Options []string `bson:"options,omitempty" json:"options,omitempty"`
 
-	Max int `bson:"max,omitempty" json:"max,omitempty"`
 	ShowIf *ShowIf `bson:"showIf,omitempty"  json:"showIf,omitempty"`
+
+	// MinSelected and MaxSelected bound how many boxes of a checkbox field may
+	// be ticked (0 means no limit). AllowOther lets multiple and checkbox
+	// answers carry a free-text value besides the options.
+	MinSelected int  `bson:"minSelected,omitempty" json:"minSelected,omitempty"`
+	MaxSelected int  `bson:"maxSelected,omitempty" json:"maxSelected,omitempty"`
+	AllowOther  bool `bson:"allowOther,omitempty" json:"allowOther,omitempty"`
+
+	// Rows and Columns lay out a matrix field. Its answer maps each row to
+	// one column, or to a list of columns when MultiSelect is set.
+	Rows        []string `bson:"rows,omitempty" json:"rows,omitempty"`
+	Columns     []string `bson:"columns,omitempty" json:"columns,omitempty"`
+	MultiSelect bool     `bson:"multiSelect,omitempty" json:"multiSelect,omitempty"`
+
+	// TopN limits a ranking to its first N places; 0 ranks every option.
+	TopN int `bson:"topN,omitempty" json:"topN,omitempty"`
+
+	// MaxFileSize (bytes), Accept (MIME types, "image/*" style wildcards) and
+	// Extensions (".pdf") restrict file uploads; empty lists allow any type.
+	MaxFileSize int64    `bson:"maxFileSize,omitempty" json:"maxFileSize,omitempty"`
+	Accept      []string `bson:"accept,omitempty" json:"accept,omitempty"`
+	Extensions  []string `bson:"extensions,omitempty" json:"extensions,omitempty"`
+
+	// Text constraints. Pattern is a regular expression the whole answer must
+	// match; PatternMessage replaces the generic error when it does not.
+	// Multiline false rejects line breaks; left unset they are accepted, as
+	// they were before the flag existed.
+	MinLength      int    `bson:"minLength,omitempty" json:"minLength,omitempty"`
+	MaxLength      int    `bson:"maxLength,omitempty" json:"maxLength,omitempty"`
+	Pattern        string `bson:"pattern,omitempty" json:"pattern,omitempty"`
+	PatternMessage string `bson:"patternMessage,omitempty" json:"patternMessage,omitempty"`
+	Multiline      *bool  `bson:"multiline,omitempty" json:"multiline,omitempty"`
+
+	// Min, Max and Step constrain number and slider answers; a rating only
+	// uses Max as its top score. The bounds are pointers because 0 is a meaningful bound.
+	Min     *float64 `bson:"min,omitempty" json:"min,omitempty"`
+	Max     *float64 `bson:"max,omitempty" json:"max,omitempty"`
+	Step    float64  `bson:"step,omitempty" json:"step,omitempty"`
+	Integer bool     `bson:"integer,omitempty" json:"integer,omitempty"`
+	Unit    string   `bson:"unit,omitempty" json:"unit,omitempty"`
+
+	// MinLabel and MaxLabel caption the ends of a slider.
+	MinLabel string `bson:"minLabel,omitempty" json:"minLabel,omitempty"`
+	MaxLabel string `bson:"maxLabel,omitempty" json:"maxLabel,omitempty"`
+
+	// Earliest and Latest bound date, time and datetime answers and are
+	// written in the same layout as the answers.
+	Earliest string `bson:"earliest,omitempty" json:"earliest,omitempty"`
+	Latest   string `bson:"latest,omitempty" json:"latest,omitempty"`
+
+	// Jumps on a page break are checked, first match wins, when leaving the
+	// page that ends at the break; without a match the next page follows.
+	Jumps []JumpRule `bson:"jumps,omitempty" json:"jumps,omitempty"`
+
+	// Content is the Markdown text of a paragraph block; ImageURL is the
+	// picture of an image block, with Label as its alt text.
+	Content  string `bson:"content,omitempty" json:"content,omitempty"`
+	ImageURL string `bson:"imageUrl,omitempty" json:"imageUrl,omitempty"`
+
+	// CountryCode is the calling code (e.g. "44") assumed for phone answers
+	// given without an international prefix.
+	CountryCode string `bson:"countryCode,omitempty" json:"countryCode,omitempty"`
 }
 
 type Form struct {
-	ID     string      `bson:"_id" json:"id"`
-	Title  string      `bson:"title" json:"title"`
-	Fields []FormField `bson:"fields" json:"fields"`
-	Status string      `bson:"status" json:"status"`
-	OwnerID string      `bson:"ownerId" json:"ownerId"`
+	ID       string      `bson:"_id" json:"id"`
+	Title    string      `bson:"title" json:"title"`
+	Fields   []FormField `bson:"fields" json:"fields"`
+	Status   string      `bson:"status" json:"status"`
+	OwnerID  string      `bson:"ownerId" json:"ownerId"`
+	Revision int         `bson:"revision" json:"revision"`
+	// Collaborators are the users the owner shared the form with.
+	Collaborators []Collaborator `bson:"collaborators,omitempty" json:"collaborators,omitempty"`
+	// DeletedAt is set while the form sits in the trash.
+	DeletedAt int64 `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
+	// Template is empty for ordinary forms, otherwise one of the Template*
+	// visibilities.
+	Template string `bson:"template,omitempty" json:"template,omitempty"`
+	// WorkspaceID is set when a workspace rather than a single user owns the
+	// form; OwnerID is empty then and access follows workspace membership.
+	WorkspaceID string `bson:"workspaceId,omitempty" json:"workspaceId,omitempty"`
+	// PublicResults lets anyone read the aggregate analytics of the form.
+	// Raw responses and exports always stay restricted to members.
+	PublicResults bool `bson:"publicResults,omitempty" json:"publicResults,omitempty"`
+}
+
+const (
+	TemplatePrivate = "private"
+	TemplatePublic  = "public"
+	TemplateSystem  = "system"
+)
+
+type Role string
+
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleOwner  Role = "owner"
+)
+
+type Collaborator struct {
+	UserID string `bson:"userId" json:"userId"`
+	Role   Role   `bson:"role" json:"role"`
+}
+
+// Workspace is a team that owns forms together. Each member holds the same
+// role on every form the workspace owns.
+type Workspace struct {
+	ID      string         `bson:"_id" json:"id"`
+	Name    string         `bson:"name" json:"name"`
+	Members []Collaborator `bson:"members" json:"members"`
+	Created int64          `bson:"created" json:"created"`
+	// Revision counts saves and guards them against concurrent edits.
+	Revision int `bson:"revision" json:"revision"`
+}
+
+// FormRevision is an immutable snapshot written on every save of a form.
+type FormRevision struct {
+	ID       string      `bson:"_id" json:"-"`
+	FormID   string      `bson:"formId" json:"formId"`
+	Revision int         `bson:"revision" json:"revision"`
+	Title    string      `bson:"title" json:"title"`
+	Fields   []FormField `bson:"fields" json:"fields"`
+	Status   string      `bson:"status" json:"status"`
+	AuthorID string      `bson:"authorId" json:"authorId"`
+	Created  int64       `bson:"created" json:"created"`
 }
 
 type Response struct {
-	ID      string                 `bson:"_id" json:"id"`
-	FormID  string                 `bson:"formId" json:"formId"`
-	UserID  string                 `bson:"userId,omitempty" json:"userId,omitempty"`
-	Answers map[string]interface{} `bson:"answers" json:"answers"`
-	Created int64                  `bson:"created" json:"created"`
+	ID           string                 `bson:"_id" json:"id"`
+	FormID       string                 `bson:"formId" json:"formId"`
+	FormRevision int                    `bson:"formRevision,omitempty" json:"formRevision,omitempty"`
+	UserID       string                 `bson:"userId,omitempty" json:"userId,omitempty"`
+	Answers      map[string]interface{} `bson:"answers" json:"answers"`
+	Created      int64                  `bson:"created" json:"created"`
+}
+
+// Upload is a file sent for a file field. It is stored before the response
+// that references it and gets ResponseID once that response is submitted.
+type Upload struct {
+	ID          string `bson:"_id" json:"id"`
+	FormID      string `bson:"formId" json:"formId"`
+	FieldID     string `bson:"fieldId" json:"fieldId"`
+	Name        string `bson:"name" json:"name"`
+	ContentType string `bson:"contentType" json:"contentType"`
+	Size        int64  `bson:"size" json:"size"`
+	ResponseID  string `bson:"responseId,omitempty" json:"responseId,omitempty"`
+	Created     int64  `bson:"created" json:"created"`
 }
 
 type User struct {
@@ -62,4 +226,4 @@ type User struct {
 	Name         string `bson:"name"        json:"name"`
 	PasswordHash []byte `bson:"passwordHash" json:"-"`
 	Created      int64  `bson:"created"     json:"created"`
-}
\ No newline at end of file
+}
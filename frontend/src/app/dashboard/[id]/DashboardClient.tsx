@@ -1,14 +1,15 @@
 'use client';
 import { useEffect, useRef, useState } from "react";
-import { getForm, getAnalytics } from "@/lib/forms";
+import { getForm, getAnalytics, getStreamToken } from "@/lib/forms";
 import type { AnalyticsSnapshot, FormDoc } from "@/lib/types";
 import { API_URL } from "@/lib/api";
+import { authHeaders, getToken } from "@/lib/auth";
 import { Chart, BarController, BarElement, CategoryScale, LinearScale, Tooltip, Legend } from "chart.js";
 
 Chart.register(BarController, BarElement, CategoryScale, LinearScale, Tooltip, Legend);
 
 async function downloadFile(url: string, filename: string) {
-  const res = await fetch(url, { cache: "no-store" });
+  const res = await fetch(url, { cache: "no-store", headers: authHeaders() });
   if (!res.ok) throw new Error(await res.text().catch(() => `HTTP ${res.status}`));
   const blob = await res.blob();
   const a = document.createElement("a");
@@ -44,16 +45,42 @@ export default function DashboardClient({ id }: { id: string }) {
   }, [id]);
 
   useEffect(() => {
-    const es = new EventSource(`${API_URL}/api/sse/${id}`);
-    es.onmessage = (ev) => {
-      try {
-        const msg = JSON.parse(ev.data);
-        if (msg?.type === "response:new" && msg.analytics) {
-          setAnalytics(msg.analytics as AnalyticsSnapshot);
-        }
-      } catch {}
+    // EventSource cannot send headers, so signed-in users open the stream with
+    // a short-lived token; a new one is fetched whenever the stream drops.
+    let es: EventSource | null = null;
+    let stop = false;
+    let retry: any;
+
+    async function open() {
+      let url = `${API_URL}/api/sse/${id}`;
+      if (getToken()) {
+        try {
+          const { token } = await getStreamToken(id);
+          url += `?token=${encodeURIComponent(token)}`;
+        } catch {}
+      }
+      if (stop) return;
+      es = new EventSource(url);
+      es.onmessage = (ev) => {
+        try {
+          const msg = JSON.parse(ev.data);
+          if (msg?.type === "response:new" && msg.analytics) {
+            setAnalytics(msg.analytics as AnalyticsSnapshot);
+          }
+        } catch {}
+      };
+      es.onerror = () => {
+        es?.close();
+        if (!stop) retry = setTimeout(open, 5000);
+      };
+    }
+
+    open();
+    return () => {
+      stop = true;
+      clearTimeout(retry);
+      es?.close();
     };
-    return () => es.close();
   }, [id]);
 
   useEffect(() => {
@@ -1,7 +1,8 @@
 'use client';
 import { useEffect, useMemo, useState } from "react";
-import { getForm, submitResponse } from "@/lib/forms";
+import { getForm, submitResponse, uploadFile, validatePage } from "@/lib/forms";
 import type { FormDoc, FormField, ShowIf } from "@/lib/types";
+import Markdown from "@/components/Markdown";
 
 function toNumber(v: any): number | null {
   if (typeof v === "number") return v;
@@ -16,8 +17,36 @@ function includes(arr: any, needle: any) {
   if (Array.isArray(arr)) return arr.map(String).includes(String(needle));
   return false;
 }
+// temporalOrdinal mirrors the server: times of day count seconds since
+// midnight, dates and date-times seconds since the epoch (UTC without a zone).
+function temporalOrdinal(v: any): number | null {
+  if (typeof v !== "string") return null;
+  const s = v.trim();
+  const t = s.match(/^(\d{2}):(\d{2})(?::(\d{2}))?$/);
+  if (t) return Number(t[1]) * 3600 + Number(t[2]) * 60 + Number(t[3] ?? 0);
+  if (!/^\d{4}-\d{2}-\d{2}/.test(s)) return null;
+  const ms = Date.parse(/T\d{2}:\d{2}(:\d{2})?$/.test(s) ? s + "Z" : s);
+  return isNaN(ms) ? null : ms / 1000;
+}
+// datetime answers and bounds are RFC 3339 in UTC while datetime-local inputs
+// show local wall time as YYYY-MM-DDTHH:MM, so values are converted both ways.
+function toLocalInput(iso?: string) {
+  if (!iso) return undefined;
+  const d = new Date(iso);
+  if (isNaN(d.getTime())) return undefined;
+  const p = (n: number) => String(n).padStart(2, "0");
+  return `${d.getFullYear()}-${p(d.getMonth() + 1)}-${p(d.getDate())}T${p(d.getHours())}:${p(d.getMinutes())}`;
+}
+function fromLocalInput(local: string) {
+  if (!local) return undefined;
+  return new Date(local).toISOString().replace(/\.\d{3}Z$/, "Z");
+}
 function numericCmp(a: any, b: any, op: string) {
-  const an = toNumber(a), bn = toNumber(b);
+  let an = toNumber(a), bn = toNumber(b);
+  if (an == null || bn == null) {
+    an = temporalOrdinal(a);
+    bn = temporalOrdinal(b);
+  }
   if (an == null || bn == null) return false;
   if (op === "gt") return an > bn;
   if (op === "gte") return an >= bn;
@@ -25,6 +54,21 @@ function numericCmp(a: any, b: any, op: string) {
   if (op === "lte") return an <= bn;
   return false;
 }
+// OTHER marks the "Other…" entry of a choice; it cannot be an option label.
+const OTHER = "\u0000other";
+// selectionHint describes a checkbox field's minSelected / maxSelected.
+function selectionHint(f: FormField) {
+  const min = f.minSelected ?? 0, max = f.maxSelected ?? 0;
+  if (min && max) return min === max ? `Select ${min}.` : `Select ${min} to ${max}.`;
+  if (min) return `Select at least ${min}.`;
+  if (max) return `Select up to ${max}.`;
+  return null;
+}
+// belowMinimum mirrors the server: an unanswered checkbox field is left to the
+// required check, a started one must reach minSelected.
+function belowMinimum(f: FormField, v: any) {
+  return f.type === "checkbox" && !!f.minSelected && Array.isArray(v) && v.length > 0 && v.length < f.minSelected;
+}
 function isVisible(field: FormField, answers: Record<string, any>) {
   if (!field.showIf) return true;
   const cond: ShowIf = field.showIf;
@@ -45,6 +89,8 @@ function isVisible(field: FormField, answers: Record<string, any>) {
 export default function FormClient({ id }: { id: string }) {
   const [form, setForm] = useState<FormDoc | null>(null);
   const [answers, setAnswers] = useState<Record<string, any>>({});
+  // otherOn keeps "Other" chosen while its text is still empty
+  const [otherOn, setOtherOn] = useState<Record<string, boolean>>({});
   const [saving, setSaving] = useState(false);
   const [msg, setMsg] = useState<string | null>(null);
   const [err, setErr] = useState<string | null>(null);
@@ -69,20 +115,61 @@ export default function FormClient({ id }: { id: string }) {
     return vis;
   }, [form, answers]);
 
+  // Page breaks split the form into pages; a break's label titles the page after it.
+  const pages = useMemo(() => {
+    const out: { title?: string; fields: FormField[] }[] = [{ fields: [] }];
+    for (const f of form?.fields ?? []) {
+      if (f.type === "pagebreak") out.push({ title: f.label || undefined, fields: [] });
+      else out[out.length - 1].fields.push(f);
+    }
+    return out;
+  }, [form]);
+  // trail holds the pages visited so far, so Back retraces any jumps
+  const [trail, setTrail] = useState([0]);
+  const page = trail[trail.length - 1];
+  const lastPage = page >= pages.length - 1;
+
   function setAnswer(fid: string, v: any) {
     setAnswers(a => ({ ...a, [fid]: v }));
   }
+  function setOther(fid: string, on: boolean) {
+    setOtherOn(o => ({ ...o, [fid]: on }));
+  }
+  const incomplete = pages[page].fields.some(f => visibleSet[f.id] && belowMinimum(f, answers[f.id]));
+
+  function visibleAnswers() {
+    const pruned: Record<string, any> = {};
+    Object.keys(answers).forEach(k => {
+      if (visibleSet[k]) pruned[k] = answers[k];
+    });
+    return pruned;
+  }
 
   async function onSubmit() {
     setSaving(true); setMsg(null); setErr(null);
     try {
-      const pruned: Record<string, any> = {};
-      Object.keys(answers).forEach(k => {
-        if (visibleSet[k]) pruned[k] = answers[k];
-      });
-      await submitResponse(id, { answers: pruned });
+      await submitResponse(id, { answers: visibleAnswers() });
       setMsg("Thanks! Your response was submitted.");
       setAnswers({});
+      setOtherOn({});
+      setTrail([0]);
+    } catch (e: any) {
+      setErr(e.message);
+    } finally {
+      setSaving(false);
+    }
+  }
+
+  // onNext lets the server validate the page and apply the jump rules.
+  async function onNext() {
+    setSaving(true); setMsg(null); setErr(null);
+    try {
+      const res = await validatePage(id, page + 1, visibleAnswers());
+      if (res.nextPage == null) {
+        await onSubmit();
+        return;
+      }
+      setTrail(t => [...t, res.nextPage! - 1]);
     } catch (e: any) {
       setErr(e.message);
     } finally {
@@ -98,23 +185,82 @@ export default function FormClient({ id }: { id: string }) {
     <div className="max-w-2xl mx-auto p-6 space-y-6">
       <h1 className="text-2xl font-bold">{form.title}</h1>
 
+      {pages.length > 1 && (
+        <div className="text-sm text-gray-500">
+          Page {page + 1} of {pages.length}{pages[page].title ? ` — ${pages[page].title}` : ""}
+        </div>
+      )}
+
       <div className="space-y-4">
-        {form.fields.map(f => {
+        {pages[page].fields.map(f => {
           if (!visibleSet[f.id]) return null;
+          if (f.type === "heading") return <h2 key={f.id} className="text-xl font-semibold">{f.label}</h2>;
+          if (f.type === "paragraph") return <Markdown key={f.id} text={f.content ?? ""} className="space-y-2 text-gray-700" />;
+          if (f.type === "image") return <img key={f.id} src={f.imageUrl} alt={f.label ?? ""} className="max-w-full rounded" />;
+          if (f.type === "divider") return <hr key={f.id} />;
           return (
             <div key={f.id} className="space-y-2">
               <label className="block font-medium">
                 {f.label}{f.required ? " *" : ""}
               </label>
 
-              {f.type === "text" && (
+              {f.type === "text" && (f.multiline ? (
+                <textarea
+                  className="w-full border rounded px-3 py-2"
+                  rows={4}
+                  minLength={f.minLength}
+                  maxLength={f.maxLength}
+                  value={answers[f.id] ?? ""}
+                  onChange={e => setAnswer(f.id, e.target.value)}
+                />
+              ) : (
                 <input
                   className="w-full border rounded px-3 py-2"
+                  minLength={f.minLength}
+                  maxLength={f.maxLength}
+                  pattern={f.pattern}
+                  title={f.patternMessage}
+                  value={answers[f.id] ?? ""}
+                  onChange={e => setAnswer(f.id, e.target.value)}
+                />
+              ))}
+
+              {(f.type === "email" || f.type === "url" || f.type === "phone") && (
+                <input
+                  type={f.type === "phone" ? "tel" : f.type}
+                  className="w-full border rounded px-3 py-2"
+                  placeholder={
+                    f.type === "email" ? "name@example.com"
+                      : f.type === "url" ? "example.com"
+                      : f.countryCode ? `+${f.countryCode} …` : "+44 20 7946 0958"
+                  }
                   value={answers[f.id] ?? ""}
                   onChange={e => setAnswer(f.id, e.target.value)}
                 />
               )}
 
+              {(f.type === "date" || f.type === "time") && (
+                <input
+                  type={f.type}
+                  className="border rounded px-3 py-2"
+                  min={f.earliest}
+                  max={f.latest}
+                  value={answers[f.id] ?? ""}
+                  onChange={e => setAnswer(f.id, e.target.value || undefined)}
+                />
+              )}
+
+              {f.type === "datetime" && (
+                <input
+                  type="datetime-local"
+                  className="border rounded px-3 py-2"
+                  min={toLocalInput(f.earliest)}
+                  max={toLocalInput(f.latest)}
+                  value={toLocalInput(answers[f.id]) ?? ""}
+                  onChange={e => setAnswer(f.id, fromLocalInput(e.target.value))}
+                />
+              )}
+
               {f.type === "rating" && (
                 <input
                   type="number"
@@ -126,51 +272,295 @@ export default function FormClient({ id }: { id: string }) {
                 />
               )}
 
-              {f.type === "multiple" && (
-                <select
-                  className="w-full border rounded px-3 py-2"
+              {f.type === "number" && (
+                <div className="flex items-center gap-2">
+                  <input
+                    type="number"
+                    className="w-40 border rounded px-3 py-2"
+                    min={f.min}
+                    max={f.max}
+                    step={f.step || (f.integer ? 1 : "any")}
+                    value={answers[f.id] ?? ""}
+                    onChange={e => setAnswer(f.id, e.target.value === "" ? undefined : Number(e.target.value))}
+                  />
+                  {f.unit && <span className="text-sm text-gray-500">{f.unit}</span>}
+                </div>
+              )}
+
+              {f.type === "slider" && (
+                <div className="flex items-center gap-3">
+                  <span className="text-sm text-gray-500">{f.minLabel ?? f.min ?? 0}</span>
+                  <input
+                    type="range"
+                    min={f.min ?? 0}
+                    max={f.max ?? 100}
+                    step={f.step || "any"}
+                    value={answers[f.id] ?? f.min ?? 0}
+                    onChange={e => setAnswer(f.id, Number(e.target.value))}
+                  />
+                  <span className="text-sm text-gray-500">{f.maxLabel ?? f.max ?? 100}</span>
+                  <span className="w-12 text-right">{answers[f.id] ?? ""}</span>
+                </div>
+              )}
+
+              {f.type === "nps" && (
+                <input
+                  type="number"
+                  min={0}
+                  max={10}
+                  step={1}
+                  className="w-24 border rounded px-3 py-2"
                   value={answers[f.id] ?? ""}
-                  onChange={e => setAnswer(f.id, e.target.value)}
+                  onChange={e => setAnswer(f.id, Number(e.target.value))}
+                />
+              )}
+
+              {f.type === "file" && (
+                <input
+                  type="file"
+                  accept={[...(f.accept ?? []), ...(f.extensions ?? [])].join(",") || undefined}
+                  onChange={async e => {
+                    const file = e.target.files?.[0];
+                    if (!file) return setAnswer(f.id, undefined);
+                    try {
+                      const up = await uploadFile(form.id, f.id, file);
+                      setAnswer(f.id, up.id);
+                    } catch (ex: any) {
+                      e.target.value = "";
+                      setErr(ex.message);
+                    }
+                  }}
+                />
+              )}
+
+              {(f.type === "multiple" || f.type === "dropdown") && (() => {
+                // a value outside the options is the free-text "other" answer
+                const v = answers[f.id];
+                const other = !!f.allowOther && (otherOn[f.id] || (typeof v === "string" && v !== "" && !f.options?.includes(v)));
+                return (
+                  <div className="space-y-2">
+                    <select
+                      className="w-full border rounded px-3 py-2"
+                      value={other ? OTHER : v ?? ""}
+                      onChange={e => {
+                        const on = e.target.value === OTHER;
+                        setOther(f.id, on);
+                        setAnswer(f.id, on ? undefined : e.target.value);
+                      }}
+                    >
+                      <option value="">Select…</option>
+                      {f.options?.map(o => <option key={o} value={o}>{o}</option>)}
+                      {f.allowOther && <option value={OTHER}>Other…</option>}
+                    </select>
+                    {other && (
+                      <input
+                        className="w-full border rounded px-3 py-2"
+                        placeholder="Please specify"
+                        aria-label={`${f.label}: other`}
+                        value={v ?? ""}
+                        onChange={e => setAnswer(f.id, e.target.value || undefined)}
+                      />
+                    )}
+                  </div>
+                );
+              })()}
+
+              {f.type === "yesno" && (
+                <select
+                  className="w-32 border rounded px-3 py-2"
+                  value={answers[f.id] === undefined ? "" : String(answers[f.id])}
+                  onChange={e => setAnswer(f.id, e.target.value === "" ? undefined : e.target.value === "true")}
                 >
                   <option value="">Select…</option>
-                  {f.options?.map(o => <option key={o} value={o}>{o}</option>)}
+                  <option value="true">Yes</option>
+                  <option value="false">No</option>
                 </select>
               )}
 
-              {f.type === "checkbox" && (
+              {f.type === "likert" && (
                 <div className="flex flex-wrap gap-3">
-                  {f.options?.map(o => {
-                    const arr = (answers[f.id] as string[] | undefined) ?? [];
-                    const checked = arr.includes(o);
-                    return (
-                      <label key={o} className="inline-flex items-center gap-2">
-                        <input
-                          type="checkbox"
-                          checked={checked}
-                          onChange={(e) => {
-                            const next = new Set(arr);
-                            e.target.checked ? next.add(o) : next.delete(o);
-                            setAnswer(f.id, Array.from(next));
-                          }}
-                        />
-                        <span>{o}</span>
-                      </label>
-                    );
-                  })}
+                  {f.options?.map((o, i) => (
+                    <label key={o} className="inline-flex items-center gap-2">
+                      <input
+                        type="radio"
+                        name={f.id}
+                        checked={answers[f.id] === i + 1}
+                        onChange={() => setAnswer(f.id, i + 1)}
+                      />
+                      <span>{o}</span>
+                    </label>
+                  ))}
                 </div>
               )}
+
+              {f.type === "matrix" && (
+                <table className="w-full text-sm">
+                  <thead>
+                    <tr>
+                      <th />
+                      {f.columns?.map(col => <th key={col} className="px-2 font-normal text-gray-600">{col}</th>)}
+                    </tr>
+                  </thead>
+                  <tbody>
+                    {f.rows?.map(row => {
+                      // the answer maps each row to a column, or to a list of columns
+                      const m: Record<string, any> = answers[f.id] ?? {};
+                      const picked: string[] = f.multiSelect ? (m[row] ?? []) : (m[row] ? [m[row]] : []);
+                      return (
+                        <tr key={row} className="border-t">
+                          <td className="py-2 pr-2">{row}</td>
+                          {f.columns?.map(col => (
+                            <td key={col} className="text-center">
+                              <input
+                                type={f.multiSelect ? "checkbox" : "radio"}
+                                name={`${f.id}:${row}`}
+                                aria-label={`${row}: ${col}`}
+                                checked={picked.includes(col)}
+                                onChange={e => {
+                                  const next = f.multiSelect
+                                    ? (e.target.checked ? [...picked, col] : picked.filter(c => c !== col))
+                                    : col;
+                                  setAnswer(f.id, { ...m, [row]: next });
+                                }}
+                              />
+                            </td>
+                          ))}
+                        </tr>
+                      );
+                    })}
+                  </tbody>
+                </table>
+              )}
+
+              {f.type === "ranking" && (() => {
+                // pick options in order of preference; the answer lists them best first
+                const ranked: string[] = answers[f.id] ?? [];
+                const places = f.topN || (f.options?.length ?? 0);
+                const move = (i: number, to: number) => {
+                  const next = [...ranked];
+                  [next[i], next[to]] = [next[to], next[i]];
+                  setAnswer(f.id, next);
+                };
+                return (
+                  <div className="space-y-2">
+                    <ol className="space-y-1">
+                      {ranked.map((o, i) => (
+                        <li key={o} className="flex items-center gap-2">
+                          <span className="w-6 text-right text-gray-500">{i + 1}.</span>
+                          <span className="flex-1">{o}</span>
+                          <button type="button" className="px-2 border rounded disabled:opacity-30" disabled={i === 0} onClick={() => move(i, i - 1)} aria-label={`Move ${o} up`}>↑</button>
+                          <button type="button" className="px-2 border rounded disabled:opacity-30" disabled={i === ranked.length - 1} onClick={() => move(i, i + 1)} aria-label={`Move ${o} down`}>↓</button>
+                          <button type="button" className="px-2 border rounded" onClick={() => setAnswer(f.id, ranked.filter(r => r !== o))} aria-label={`Remove ${o}`}>✕</button>
+                        </li>
+                      ))}
+                    </ol>
+                    {ranked.length < places && (
+                      <div className="flex flex-wrap gap-2">
+                        <span className="text-sm text-gray-500">
+                          {f.topN ? `Pick your top ${places}:` : "Rank every option:"}
+                        </span>
+                        {f.options?.filter(o => !ranked.includes(o)).map(o => (
+                          <button key={o} type="button" className="px-2 py-1 border rounded text-sm" onClick={() => setAnswer(f.id, [...ranked, o])}>
+                            {o}
+                          </button>
+                        ))}
+                      </div>
+                    )}
+                  </div>
+                );
+              })()}
+
+              {f.type === "checkbox" && (() => {
+                const arr = (answers[f.id] as string[] | undefined) ?? [];
+                const picked = arr.filter(v => f.options?.includes(v));
+                const otherText = arr.find(v => !f.options?.includes(v));
+                const other = !!f.allowOther && (otherOn[f.id] || otherText !== undefined);
+                // "other" counts as a selection, as on the server
+                const count = picked.length + (other ? 1 : 0);
+                const full = !!f.maxSelected && count >= f.maxSelected;
+                const hint = selectionHint(f);
+                return (
+                  <div className="space-y-2">
+                    <div className="flex flex-wrap gap-3">
+                      {f.options?.map(o => {
+                        const checked = arr.includes(o);
+                        return (
+                          <label key={o} className="inline-flex items-center gap-2">
+                            <input
+                              type="checkbox"
+                              checked={checked}
+                              disabled={!checked && full}
+                              onChange={(e) => {
+                                const next = new Set(arr);
+                                e.target.checked ? next.add(o) : next.delete(o);
+                                setAnswer(f.id, Array.from(next));
+                              }}
+                            />
+                            <span>{o}</span>
+                          </label>
+                        );
+                      })}
+                      {f.allowOther && (
+                        <span className="inline-flex items-center gap-2">
+                          <label className="inline-flex items-center gap-2">
+                            <input
+                              type="checkbox"
+                              checked={other}
+                              disabled={!other && full}
+                              onChange={e => {
+                                setOther(f.id, e.target.checked);
+                                if (!e.target.checked) setAnswer(f.id, picked);
+                              }}
+                            />
+                            <span>Other</span>
+                          </label>
+                          {other && (
+                            <input
+                              className="border rounded px-2 py-1"
+                              placeholder="Please specify"
+                              aria-label={`${f.label}: other`}
+                              value={otherText ?? ""}
+                              onChange={e => setAnswer(f.id, e.target.value ? [...picked, e.target.value] : picked)}
+                            />
+                          )}
+                        </span>
+                      )}
+                    </div>
+                    {hint && (
+                      <div className={`text-sm ${belowMinimum(f, arr) ? "text-red-600" : "text-gray-500"}`}>{hint}</div>
+                    )}
+                  </div>
+                );
+              })()}
             </div>
           );
         })}
       </div>
 
-      <button
-        className="px-4 py-2 rounded bg-black text-white disabled:opacity-50"
-        disabled={saving}
-        onClick={onSubmit}
-      >
-        {saving ? "Submitting…" : "Submit"}
-      </button>
+      <div className="flex gap-3">
+        {page > 0 && (
+          <button className="px-4 py-2 rounded border" onClick={() => setTrail(t => t.slice(0, -1))}>
+            Back
+          </button>
+        )}
+        {lastPage ? (
+          <button
+            className="px-4 py-2 rounded bg-black text-white disabled:opacity-50"
+            disabled={saving || incomplete}
+            onClick={onSubmit}
+          >
+            {saving ? "Submitting…" : "Submit"}
+          </button>
+        ) : (
+          <button
+            className="px-4 py-2 rounded bg-black text-white disabled:opacity-50"
+            disabled={saving || incomplete}
+            onClick={onNext}
+          >
+            Next
+          </button>
+        )}
+      </div>
 
       {msg && <div className="text-green-600">{msg}</div>}
       {err && <div className="text-red-600">{err}</div>}
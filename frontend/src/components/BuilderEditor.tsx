@@ -51,6 +51,7 @@ export default function BuilderEditor({ initial }: { initial?: FormDoc | null })
   );
   const [status, setStatus] = useState<FormDoc["status"]>(initial?.status ?? "draft");
   const [formId, setFormId] = useState<string>(initial?.id ?? "");
+  const [revision, setRevision] = useState<number | undefined>(initial?.revision);
   const [saving, setSaving] = useState(false);
   const [msg, setMsg] = useState<string | null>(null);
   const [err, setErr] = useState<string | null>(null);
@@ -61,6 +62,7 @@ export default function BuilderEditor({ initial }: { initial?: FormDoc | null })
     setFields(initial.fields.map(f => ({ ...f, key: uid("k") })));
     setStatus(initial.status);
     setFormId(initial.id);
+    setRevision(initial.revision);
   }, [initial]);
 
   const addField = (t: FieldType) => {
@@ -128,15 +130,16 @@ export default function BuilderEditor({ initial }: { initial?: FormDoc | null })
     setSaving(true); setMsg(null); setErr(null);
     try {
       const payload = { title: title.trim(), status, fields: fields.map(({ key, ...rest }) => rest) };
-      const res = formId ? await updateForm(formId, payload) : await createForm(payload);
+      const res = formId ? await updateForm(formId, payload, revision) : await createForm(payload);
       setFormId(res.id);
+      setRevision(res.revision);
       setMsg(`Saved! Form ID: ${res.id}`);
     } catch (e: any) {
       setErr(e.message);
     } finally {
       setSaving(false);
     }
-  }, [title, status, fields, formId]);
+  }, [title, status, fields, formId, revision]);
 
   const dependencyChoices = (selfIdx: number) => fields.slice(0, selfIdx);
   const operatorChoices: { value: ConditionOperator; label: string }[] = [
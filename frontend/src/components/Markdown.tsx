@@ -0,0 +1,94 @@
+import type { ReactNode } from "react";
+
+// Markdown renders the small subset of Markdown allowed in paragraph blocks:
+// #-headings, "-" / "*" and "1." lists, blank-line separated paragraphs,
+// **bold**, *italic* / _italic_, `code` and [links](https://…). It builds
+// React elements rather than HTML, so form content cannot inject markup.
+export default function Markdown({ text, className }: { text: string; className?: string }) {
+  return <div className={className}>{blocks(text)}</div>;
+}
+
+const headingClass = ["text-xl font-semibold", "text-lg font-semibold", "font-semibold"];
+
+function blocks(text: string): ReactNode[] {
+  const out: ReactNode[] = [];
+  const lines = text.replace(/\r\n?/g, "\n").split("\n");
+  let i = 0;
+  while (i < lines.length) {
+    const line = lines[i];
+    if (line.trim() === "") {
+      i++;
+      continue;
+    }
+
+    const h = line.match(/^(#{1,6})\s+(.*)$/);
+    if (h) {
+      const level = h[1].length;
+      const Tag = `h${Math.min(level + 1, 6)}` as "h2";
+      out.push(<Tag key={i} className={headingClass[Math.min(level, 3) - 1]}>{inline(h[2])}</Tag>);
+      i++;
+      continue;
+    }
+
+    const list = listItem(line);
+    if (list) {
+      const items: ReactNode[] = [];
+      const start = i;
+      while (i < lines.length && listItem(lines[i])?.ordered === list.ordered) {
+        items.push(<li key={i}>{inline(listItem(lines[i])!.text)}</li>);
+        i++;
+      }
+      out.push(list.ordered
+        ? <ol key={start} className="list-decimal pl-6">{items}</ol>
+        : <ul key={start} className="list-disc pl-6">{items}</ul>);
+      continue;
+    }
+
+    const start = i;
+    const para: ReactNode[] = [];
+    while (i < lines.length && lines[i].trim() !== "" && !/^#{1,6}\s/.test(lines[i]) && !listItem(lines[i])) {
+      if (i > start) para.push(<br key={`br${i}`} />);
+      para.push(...inline(lines[i], `l${i}`));
+      i++;
+    }
+    out.push(<p key={start}>{para}</p>);
+  }
+  return out;
+}
+
+function listItem(line: string): { ordered: boolean; text: string } | null {
+  const m = line.match(/^\s*([-*]|\d+\.)\s+(.*)$/);
+  if (!m) return null;
+  return { ordered: m[1].endsWith("."), text: m[2] };
+}
+
+// Earlier alternatives win, so code spans keep their content literal; _ only
+// emphasizes at word boundaries so snake_case names stay as written.
+const inlinePattern = /`([^`]+)`|\*\*(.+?)\*\*|\*(.+?)\*|(?<!\w)_(.+?)_(?!\w)|\[([^\]]+)\]\(([^)\s]+)\)/g;
+
+function inline(text: string, keyPrefix = ""): ReactNode[] {
+  const out: ReactNode[] = [];
+  let last = 0;
+  let n = 0;
+  for (const m of text.matchAll(inlinePattern)) {
+    const at = m.index ?? 0;
+    if (at > last) out.push(text.slice(last, at));
+    const key = `${keyPrefix}:${n++}`;
+    if (m[1] !== undefined) out.push(<code key={key} className="px-1 rounded bg-gray-100">{m[1]}</code>);
+    else if (m[2] !== undefined) out.push(<strong key={key}>{inline(m[2], key)}</strong>);
+    else if (m[3] !== undefined || m[4] !== undefined) out.push(<em key={key}>{inline(m[3] ?? m[4] ?? "", key)}</em>);
+    else if (safeHref(m[6])) {
+      out.push(<a key={key} href={m[6]} target="_blank" rel="noopener noreferrer" className="underline">{inline(m[5], key)}</a>);
+    } else {
+      out.push(m[0]);
+    }
+    last = at + m[0].length;
+  }
+  if (last < text.length) out.push(text.slice(last));
+  return out;
+}
+
+// Only web and mail links are followed; javascript: and friends stay text.
+function safeHref(href: string) {
+  return /^(https?:\/\/|mailto:)/i.test(href);
+}
@@ -1,5 +1,5 @@
-import { api } from "./api";
-import type { FormDoc, AnalyticsSnapshot, SubmitResponseBody } from "./types";
+import { api, API_URL } from "./api";
+import type { FormDoc, AnalyticsSnapshot, SubmitResponseBody, UploadedFile } from "./types";
 
 function getToken(): string | null {
   if (typeof window === "undefined") return null;
@@ -33,11 +33,15 @@ export const createForm = (doc: Partial<FormDoc>) =>
     )
   );
 
-export const updateForm = (id: string, doc: Partial<FormDoc>) =>
+export const updateForm = (id: string, doc: Partial<FormDoc>, revision?: number) =>
   api<FormDoc>(
     `/api/forms/${id}`,
     withAuthHeaders(
-      withJson({ method: "PUT", body: JSON.stringify(doc) })
+      withJson({
+        method: "PUT",
+        body: JSON.stringify(doc),
+        headers: revision ? { "If-Match": `"${revision}"` } : undefined,
+      })
     )
   );
 
@@ -55,5 +59,32 @@ export const submitResponse = (id: string, body: SubmitResponseBody) =>
     )
   );
 
+// validatePage checks one page (1-based) server-side and returns the page the
+// jump rules lead to next, null once the form can be submitted.
+export const validatePage = (id: string, page: number, answers: Record<string, any>) =>
+  api<{ page: number; pages: number; nextPage: number | null }>(
+    `/api/forms/${id}/validate-page`,
+    withJson({ method: "POST", body: JSON.stringify({ page, answers }) })
+  );
+
 export const getAnalytics = (id: string) =>
-  api<AnalyticsSnapshot>(`/api/forms/${id}/analytics`);
+  api<AnalyticsSnapshot>(`/api/forms/${id}/analytics`, withAuthHeaders());
+
+export const getStreamToken = (id: string) =>
+  api<{ token: string; expiresAt: number }>(
+    `/api/forms/${id}/stream-token`,
+    withAuthHeaders({ method: "POST" })
+  );
+
+// uploadFile bypasses api() since the browser must set the multipart
+// Content-Type boundary itself.
+export async function uploadFile(formId: string, fieldId: string, file: File): Promise<UploadedFile> {
+  const body = new FormData();
+  body.append("file", file);
+  const res = await fetch(`${API_URL}/api/forms/${formId}/files/${fieldId}`, { method: "POST", body });
+  if (!res.ok) {
+    const text = await res.text().catch(() => "");
+    throw new Error(text || `Upload failed: ${res.status}`);
+  }
+  return res.json() as Promise<UploadedFile>;
+}
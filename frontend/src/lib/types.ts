@@ -1,4 +1,7 @@
-export type FieldType = "text" | "multiple" | "checkbox" | "rating";
+export type FieldType = "text" | "multiple" | "checkbox" | "rating" | "nps" | "file" | "dropdown" | "yesno" | "likert" | "slider"
+  | "date" | "time" | "datetime" | "number" | "email" | "url" | "phone"
+  | "matrix" | "ranking"
+  | "heading" | "paragraph" | "image" | "divider" | "pagebreak";
 
 export type ConditionOperator = "eq" | "ne" | "includes" | "gt" | "gte" | "lt" | "lte";
 
@@ -14,8 +17,49 @@ export interface FormField {
   label: string;
   required?: boolean;
   options?: string[];
+  minSelected?: number;
+  maxSelected?: number;
+  allowOther?: boolean;
+  rows?: string[];
+  columns?: string[];
+  multiSelect?: boolean;
+  topN?: number;
+  maxFileSize?: number;
+  accept?: string[];
+  extensions?: string[];
+  min?: number;
   max?: number;
+  step?: number;
+  integer?: boolean;
+  unit?: string;
+  minLabel?: string;
+  maxLabel?: string;
+  earliest?: string;
+  latest?: string;
   showIf?: ShowIf;
+  jumps?: JumpRule[];
+  content?: string;
+  imageUrl?: string;
+  minLength?: number;
+  maxLength?: number;
+  pattern?: string;
+  patternMessage?: string;
+  multiline?: boolean;
+  countryCode?: string;
+}
+
+// JumpRule on a page break: when `if` holds on leaving the page, continue at
+// the page break `to` (or "end").
+export interface JumpRule {
+  if: ShowIf;
+  to: string;
+}
+
+export interface UploadedFile {
+  id: string;
+  name: string;
+  size: number;
+  contentType: string;
 }
 
 export interface FormDoc {
@@ -23,10 +67,15 @@ export interface FormDoc {
   title: string;
   fields: FormField[];
   status: "draft" | "published";
+  revision?: number;
+  workspaceId?: string;
+  publicResults?: boolean;
 }
 
 export interface Trends {
   avgRating?: number;
+  nps?: number;
+  npsTrend?: { period: string; nps: number; count: number }[];
   mostCommon?: Record<string, string | string[]>;
   skipped?: Record<string, number>;
   mostSkipped?: { id: string; label: string; skipped: number; total: number }[];